@@ -0,0 +1,85 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package features
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// AreaApprovers records the approvers from the OWNERS hierarchy for
+// each area, keyed by the area name without the "area/" label prefix.
+type AreaApprovers struct {
+	approvers map[string]sets.String
+}
+
+// NewAreaApprovers returns an empty approvers record.
+func NewAreaApprovers() *AreaApprovers {
+	return &AreaApprovers{approvers: map[string]sets.String{}}
+}
+
+// AddApprovers records the given logins as approvers for the given
+// area.
+func (a *AreaApprovers) AddApprovers(area string, logins ...string) {
+	set, found := a.approvers[area]
+	if !found {
+		set = sets.NewString()
+		a.approvers[area] = set
+	}
+	for _, login := range logins {
+		login = strings.TrimSpace(login)
+		if login == "" {
+			continue
+		}
+		set.Insert(login)
+	}
+}
+
+// Approvers returns the sorted logins of the approvers for the given
+// area. An area without an OWNERS mapping yields an empty slice.
+func (a *AreaApprovers) Approvers(area string) []string {
+	set, found := a.approvers[area]
+	if !found {
+		return []string{}
+	}
+	return set.List()
+}
+
+// LoadFromFile populates approvers from a file with one area per line
+// in the form "area-name=approver1,approver2". Blank lines and '#'
+// comments are ignored.
+func (a *AreaApprovers) LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, "=", 2)
+		if len(fields) != 2 {
+			return fmt.Errorf("invalid area approvers line %q", line)
+		}
+		a.AddApprovers(strings.TrimSpace(fields[0]), strings.Split(fields[1], ",")...)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package features provides shared functionality that individual mungers
+// may declare a dependency on via RequiredFeatures.
+package features
+
+import (
+	"k8s.io/test-infra/mungegithub/github"
+	"k8s.io/test-infra/mungegithub/options"
+)
+
+// Feature is the interface implemented by shared munger functionality.
+type Feature interface {
+	Name() string
+	Initialize(config *github.Config) error
+	EachLoop() error
+	RegisterOptions(opts *options.Options)
+}
+
+// Features is the collection of initialized features available to
+// mungers.
+type Features struct {
+	features map[string]Feature
+
+	// SigMembership records the known members of each sig, for
+	// mungers that need to authorize sig-scoped actions.
+	SigMembership *SigMembership
+
+	// AreaApprovers records the OWNERS approvers for each area, for
+	// mungers that need to name who can approve an issue.
+	AreaApprovers *AreaApprovers
+}
+
+// NewFeatures returns an empty feature collection.
+func NewFeatures() *Features {
+	return &Features{
+		features:      map[string]Feature{},
+		SigMembership: NewSigMembership(),
+		AreaApprovers: NewAreaApprovers(),
+	}
+}
+
+// Initialize initializes the named features against the given config.
+func (f *Features) Initialize(config *github.Config, requestedFeatures []string) error {
+	for _, name := range requestedFeatures {
+		feature, found := f.features[name]
+		if !found {
+			continue
+		}
+		if err := feature.Initialize(config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EachLoop calls EachLoop on every initialized feature.
+func (f *Features) EachLoop() error {
+	for _, feature := range f.features {
+		if err := feature.EachLoop(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
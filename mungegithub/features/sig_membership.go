@@ -0,0 +1,93 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package features
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// SigMembership records the known members of each special interest
+// group, keyed by the sig name without the "sig/" label prefix.
+type SigMembership struct {
+	members map[string]sets.String
+}
+
+// NewSigMembership returns an empty membership record.
+func NewSigMembership() *SigMembership {
+	return &SigMembership{members: map[string]sets.String{}}
+}
+
+// AddMembers records the given logins as members of the given sig.
+func (s *SigMembership) AddMembers(sig string, logins ...string) {
+	set, found := s.members[sig]
+	if !found {
+		set = sets.NewString()
+		s.members[sig] = set
+	}
+	for _, login := range logins {
+		login = strings.TrimSpace(login)
+		if login == "" {
+			continue
+		}
+		set.Insert(strings.ToLower(login))
+	}
+}
+
+// IsMember returns whether the given login is a member of the given
+// sig. Login comparison is case-insensitive.
+func (s *SigMembership) IsMember(sig, login string) bool {
+	set, found := s.members[sig]
+	if !found {
+		return false
+	}
+	return set.Has(strings.ToLower(login))
+}
+
+// Members returns the sorted logins of the members of the given sig.
+func (s *SigMembership) Members(sig string) []string {
+	set, found := s.members[sig]
+	if !found {
+		return []string{}
+	}
+	return set.List()
+}
+
+// LoadFromFile populates membership from a file with one sig per line
+// in the form "sig-name=member1,member2". Blank lines and '#' comments
+// are ignored.
+func (s *SigMembership) LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, "=", 2)
+		if len(fields) != 2 {
+			return fmt.Errorf("invalid sig membership line %q", line)
+		}
+		s.AddMembers(strings.TrimSpace(fields[0]), strings.Split(fields[1], ",")...)
+	}
+	return nil
+}
@@ -0,0 +1,1092 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package github provides an abstraction over the github API that caches
+// issue state per munge loop and applies mutations both remotely and to
+// the local cache so that mungers always observe their own writes.
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	githubapi "github.com/google/go-github/github"
+	"golang.org/x/oauth2"
+)
+
+// Config holds the github client and the information needed to munge a
+// single repository.
+type Config struct {
+	client *githubapi.Client
+
+	// Org is the github organization the munger operates on.
+	Org string
+	// Project is the github repository the munger operates on.
+	Project string
+	// BotName is the login of the user the token belongs to.
+	BotName string
+	// Token is the github oauth token.
+	Token string
+	// Endpoint is the github API base URL, for github enterprise installs.
+	Endpoint string
+	// DryRun indicates that no mutations should reach github.
+	DryRun bool
+
+	apiCallLock   sync.Mutex
+	apiCallCounts map[string]int
+}
+
+// countAPICall tallies a github API interaction by type, so that
+// operators can measure how heavily a munger consumes the rate limit.
+// Dry-run interactions are counted as well, since they stand in for
+// the calls a real run would make.
+func (config *Config) countAPICall(callType string) {
+	config.apiCallLock.Lock()
+	defer config.apiCallLock.Unlock()
+	if config.apiCallCounts == nil {
+		config.apiCallCounts = map[string]int{}
+	}
+	config.apiCallCounts[callType]++
+}
+
+// APICallCounts returns a copy of the per-type github API call tallies.
+func (config *Config) APICallCounts() map[string]int {
+	config.apiCallLock.Lock()
+	defer config.apiCallLock.Unlock()
+	counts := map[string]int{}
+	for callType, count := range config.apiCallCounts {
+		counts[callType] = count
+	}
+	return counts
+}
+
+// ResetAPICallCounts returns the current github API call tallies and
+// clears them, for per-loop accounting.
+func (config *Config) ResetAPICallCounts() map[string]int {
+	config.apiCallLock.Lock()
+	defer config.apiCallLock.Unlock()
+	counts := config.apiCallCounts
+	config.apiCallCounts = nil
+	if counts == nil {
+		counts = map[string]int{}
+	}
+	return counts
+}
+
+// PreExecute initializes the github client from the configured token and
+// endpoint. It must be called before any MungeObject is constructed.
+func (config *Config) PreExecute() error {
+	httpClient := oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: config.Token},
+	))
+	client := githubapi.NewClient(httpClient)
+	if config.Endpoint != "" {
+		endpoint := config.Endpoint
+		if !strings.HasSuffix(endpoint, "/") {
+			endpoint += "/"
+		}
+		baseURL, err := url.Parse(endpoint)
+		if err != nil {
+			return fmt.Errorf("invalid github endpoint %q: %v", config.Endpoint, err)
+		}
+		client.BaseURL = baseURL
+	}
+	config.client = client
+	return nil
+}
+
+// MungeObject is the object that mungers deal with. It wraps a github
+// issue together with lazily fetched, per-loop caches of its comments and
+// events.
+type MungeObject struct {
+	config      *Config
+	Issue       *githubapi.Issue
+	comments    []*githubapi.IssueComment
+	events      []*githubapi.IssueEvent
+	eventsErr   bool
+	timeline    []*githubapi.Timeline
+	referenced  map[int]*githubapi.Issue
+	prBases     map[int]string
+	prReviews   map[int][]*githubapi.PullRequestReview
+	issueType   *string
+	Annotations map[string]string
+}
+
+// NewTestObject constructs a MungeObject from static data for use in
+// tests. The comment and event caches are considered already fetched
+// even when empty so that no API calls are attempted.
+func NewTestObject(config *Config, issue *githubapi.Issue, comments []*githubapi.IssueComment, events []*githubapi.IssueEvent) *MungeObject {
+	if comments == nil {
+		comments = []*githubapi.IssueComment{}
+	}
+	if events == nil {
+		events = []*githubapi.IssueEvent{}
+	}
+	return &MungeObject{
+		config:      config,
+		Issue:       issue,
+		comments:    comments,
+		events:      events,
+		Annotations: map[string]string{},
+	}
+}
+
+// NewTestObjectWithTimeline constructs a MungeObject from static data
+// including a timeline and the issues it references, for use in tests
+// of timeline-dependent behavior.
+func NewTestObjectWithTimeline(config *Config, issue *githubapi.Issue, comments []*githubapi.IssueComment, events []*githubapi.IssueEvent, timeline []*githubapi.Timeline, referenced []*githubapi.Issue) *MungeObject {
+	obj := NewTestObject(config, issue, comments, events)
+	if timeline == nil {
+		timeline = []*githubapi.Timeline{}
+	}
+	obj.timeline = timeline
+	obj.referenced = map[int]*githubapi.Issue{}
+	for _, ref := range referenced {
+		if ref.Number != nil {
+			obj.referenced[*ref.Number] = ref
+		}
+	}
+	return obj
+}
+
+// NewObject constructs a MungeObject for the issue with the given number.
+func (config *Config) NewObject(number int) (*MungeObject, error) {
+	config.countAPICall("get-issue")
+	issue, _, err := config.client.Issues.Get(context.Background(), config.Org, config.Project, number)
+	if err != nil {
+		return nil, err
+	}
+	return &MungeObject{
+		config:      config,
+		Issue:       issue,
+		Annotations: map[string]string{},
+	}, nil
+}
+
+// ForEachIssueDo will run the provided function on every open issue in
+// the configured repository.
+func (config *Config) ForEachIssueDo(fn func(obj *MungeObject) error) error {
+	page := 1
+	for {
+		listOpts := &githubapi.IssueListByRepoOptions{
+			State:       "open",
+			Sort:        "created",
+			ListOptions: githubapi.ListOptions{PerPage: 100, Page: page},
+		}
+		issues, response, err := config.client.Issues.ListByRepo(context.Background(), config.Org, config.Project, listOpts)
+		if err != nil {
+			return err
+		}
+		for i := range issues {
+			issue := issues[i]
+			if issue.Number == nil {
+				glog.Infof("Skipping issue with no number: %v", issue)
+				continue
+			}
+			obj := &MungeObject{
+				config:      config,
+				Issue:       issue,
+				Annotations: map[string]string{},
+			}
+			if err := fn(obj); err != nil {
+				return err
+			}
+		}
+		if response.NextPage == 0 {
+			break
+		}
+		page = response.NextPage
+	}
+	return nil
+}
+
+// Repo returns the "org/project" slug of the repository the object
+// belongs to.
+func (obj *MungeObject) Repo() string {
+	return fmt.Sprintf("%s/%s", obj.config.Org, obj.config.Project)
+}
+
+// Number returns the issue number of the object.
+func (obj *MungeObject) Number() int {
+	if obj.Issue == nil || obj.Issue.Number == nil {
+		return 0
+	}
+	return *obj.Issue.Number
+}
+
+// IsPR returns whether the object is a pull request.
+func (obj *MungeObject) IsPR() bool {
+	return obj.Issue != nil && obj.Issue.PullRequestLinks != nil
+}
+
+// HasLabel returns whether the issue has the given label.
+func (obj *MungeObject) HasLabel(name string) bool {
+	for i := range obj.Issue.Labels {
+		label := &obj.Issue.Labels[i]
+		if label.Name != nil && *label.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AddLabel adds the given label to the issue and updates the locally
+// cached labels on success.
+func (obj *MungeObject) AddLabel(name string) error {
+	if obj.HasLabel(name) {
+		return nil
+	}
+	obj.config.countAPICall("add-label")
+	glog.Infof("Adding label %q to %d", name, obj.Number())
+	if !obj.config.DryRun {
+		_, _, err := obj.config.client.Issues.AddLabelsToIssue(context.Background(), obj.config.Org, obj.config.Project, obj.Number(), []string{name})
+		if err != nil {
+			return err
+		}
+	}
+	obj.Issue.Labels = append(obj.Issue.Labels, githubapi.Label{Name: &name})
+	return nil
+}
+
+// RemoveLabel removes the given label from the issue and updates the
+// locally cached labels on success.
+func (obj *MungeObject) RemoveLabel(name string) error {
+	if !obj.HasLabel(name) {
+		return nil
+	}
+	obj.config.countAPICall("remove-label")
+	glog.Infof("Removing label %q from %d", name, obj.Number())
+	if !obj.config.DryRun {
+		_, err := obj.config.client.Issues.RemoveLabelForIssue(context.Background(), obj.config.Org, obj.config.Project, obj.Number(), name)
+		if err != nil {
+			return err
+		}
+	}
+	labels := []githubapi.Label{}
+	for _, label := range obj.Issue.Labels {
+		if label.Name == nil || *label.Name != name {
+			labels = append(labels, label)
+		}
+	}
+	obj.Issue.Labels = labels
+	return nil
+}
+
+// ReleaseMilestone returns the title of the milestone the issue is
+// assigned to, or the empty string if it has none.
+func (obj *MungeObject) ReleaseMilestone() (string, bool) {
+	if obj.Issue.Milestone == nil || obj.Issue.Milestone.Title == nil {
+		return "", true
+	}
+	return *obj.Issue.Milestone.Title, true
+}
+
+// ClearMilestone removes the issue from its milestone.
+func (obj *MungeObject) ClearMilestone() error {
+	if obj.Issue.Milestone == nil {
+		return nil
+	}
+	obj.config.countAPICall("clear-milestone")
+	glog.Infof("Clearing milestone from %d", obj.Number())
+	if !obj.config.DryRun {
+		// IssueRequest elides an empty milestone field, so a raw request
+		// with an explicit null is required to clear the milestone.
+		u := fmt.Sprintf("repos/%v/%v/issues/%d", obj.config.Org, obj.config.Project, obj.Number())
+		body := &struct {
+			Milestone interface{} `json:"milestone"`
+		}{}
+		req, err := obj.config.client.NewRequest("PATCH", u, body)
+		if err != nil {
+			return err
+		}
+		if _, err := obj.config.client.Do(context.Background(), req, nil); err != nil {
+			return err
+		}
+	}
+	obj.Issue.Milestone = nil
+	return nil
+}
+
+// SetMilestone assigns the issue to the milestone with the given title.
+func (obj *MungeObject) SetMilestone(title string) error {
+	milestone, err := obj.config.milestoneForTitle(title)
+	if err != nil {
+		return err
+	}
+	obj.config.countAPICall("set-milestone")
+	glog.Infof("Setting milestone of %d to %q", obj.Number(), title)
+	if !obj.config.DryRun {
+		if milestone == nil || milestone.Number == nil {
+			return fmt.Errorf("no milestone found with title %q", title)
+		}
+		request := &githubapi.IssueRequest{Milestone: milestone.Number}
+		if _, _, err := obj.config.client.Issues.Edit(context.Background(), obj.config.Org, obj.config.Project, obj.Number(), request); err != nil {
+			return err
+		}
+	}
+	if milestone == nil {
+		milestone = &githubapi.Milestone{Title: &title}
+	}
+	obj.Issue.Milestone = milestone
+	return nil
+}
+
+// MilestoneExists returns whether an open milestone with the given
+// title exists in the repository.
+func (config *Config) MilestoneExists(title string) (bool, error) {
+	if config.client == nil {
+		return false, fmt.Errorf("github client is not initialized")
+	}
+	milestone, err := config.milestoneForTitle(title)
+	if err != nil {
+		return false, err
+	}
+	return milestone != nil, nil
+}
+
+// milestoneForTitle finds the open milestone with the given title.
+func (config *Config) milestoneForTitle(title string) (*githubapi.Milestone, error) {
+	if config.client == nil {
+		return nil, nil
+	}
+	config.countAPICall("list-milestones")
+	milestones, _, err := config.client.Issues.ListMilestones(context.Background(), config.Org, config.Project, &githubapi.MilestoneListOptions{State: "open"})
+	if err != nil {
+		return nil, err
+	}
+	for _, milestone := range milestones {
+		if milestone.Title != nil && *milestone.Title == title {
+			return milestone, nil
+		}
+	}
+	return nil, nil
+}
+
+// maxListPages bounds how many pages of comments, events or timeline
+// entries are fetched for a single issue. Listings that exceed the
+// bound are treated as failures rather than silently truncated, since
+// callers rely on seeing the complete history (e.g. the most recent
+// label event) to make removal decisions.
+const maxListPages = 100
+
+// ListComments returns all comments on the issue, fetching and caching
+// them if they have not already been fetched this loop.
+func (obj *MungeObject) ListComments() ([]*githubapi.IssueComment, bool) {
+	if obj.comments != nil {
+		return obj.comments, true
+	}
+	obj.config.countAPICall("list-comments")
+	if obj.config.client == nil {
+		obj.comments = []*githubapi.IssueComment{}
+		return obj.comments, true
+	}
+	comments := []*githubapi.IssueComment{}
+	page := 1
+	for {
+		listOpts := &githubapi.IssueListCommentsOptions{
+			ListOptions: githubapi.ListOptions{PerPage: 100, Page: page},
+		}
+		result, response, err := obj.config.client.Issues.ListComments(context.Background(), obj.config.Org, obj.config.Project, obj.Number(), listOpts)
+		if err != nil {
+			glog.Errorf("Failed to list comments for %d: %v", obj.Number(), err)
+			return nil, false
+		}
+		comments = append(comments, result...)
+		if response.NextPage == 0 {
+			break
+		}
+		if page >= maxListPages {
+			glog.Errorf("Comment listing for %d exceeds %d pages; treating as unavailable", obj.Number(), maxListPages)
+			return nil, false
+		}
+		page = response.NextPage
+	}
+	obj.comments = comments
+	return comments, true
+}
+
+// RecentComments returns the most recent page of comments, bypassing
+// the per-loop cache so that a caller can detect comments posted since
+// the cache was filled. Without a client the cached comments are
+// returned.
+func (obj *MungeObject) RecentComments() ([]*githubapi.IssueComment, bool) {
+	if obj.config.client == nil {
+		return obj.ListComments()
+	}
+	obj.config.countAPICall("list-recent-comments")
+	listOpts := &githubapi.IssueListCommentsOptions{
+		Sort:        "created",
+		Direction:   "desc",
+		ListOptions: githubapi.ListOptions{PerPage: 100},
+	}
+	result, _, err := obj.config.client.Issues.ListComments(context.Background(), obj.config.Org, obj.config.Project, obj.Number(), listOpts)
+	if err != nil {
+		glog.Errorf("Failed to list recent comments for %d: %v", obj.Number(), err)
+		return nil, false
+	}
+	return result, true
+}
+
+// WriteComment posts the given message as a new comment on the issue.
+func (obj *MungeObject) WriteComment(msg string) error {
+	obj.config.countAPICall("post-comment")
+	glog.Infof("Commenting %q on %d", msg, obj.Number())
+	comment := &githubapi.IssueComment{
+		Body:      &msg,
+		CreatedAt: timePtr(time.Now()),
+		User:      &githubapi.User{Login: &obj.config.BotName},
+	}
+	if !obj.config.DryRun {
+		result, _, err := obj.config.client.Issues.CreateComment(context.Background(), obj.config.Org, obj.config.Project, obj.Number(), &githubapi.IssueComment{Body: &msg})
+		if err != nil {
+			return err
+		}
+		comment = result
+	}
+	if obj.comments != nil {
+		obj.comments = append(obj.comments, comment)
+	}
+	return nil
+}
+
+// EditComment updates the body of an existing comment on the issue.
+func (obj *MungeObject) EditComment(comment *githubapi.IssueComment, msg string) error {
+	obj.config.countAPICall("edit-comment")
+	glog.Infof("Editing comment %v on %d", comment.ID, obj.Number())
+	if !obj.config.DryRun {
+		if comment.ID == nil {
+			return fmt.Errorf("comment has no ID")
+		}
+		_, _, err := obj.config.client.Issues.EditComment(context.Background(), obj.config.Org, obj.config.Project, *comment.ID, &githubapi.IssueComment{Body: &msg})
+		if err != nil {
+			return err
+		}
+	}
+	comment.Body = &msg
+	return nil
+}
+
+// DeleteComment removes the given comment from the issue.
+func (obj *MungeObject) DeleteComment(comment *githubapi.IssueComment) error {
+	obj.config.countAPICall("delete-comment")
+	glog.Infof("Deleting comment %v from %d", comment.ID, obj.Number())
+	if !obj.config.DryRun {
+		if comment.ID == nil {
+			return fmt.Errorf("comment has no ID")
+		}
+		if _, err := obj.config.client.Issues.DeleteComment(context.Background(), obj.config.Org, obj.config.Project, *comment.ID); err != nil {
+			return err
+		}
+	}
+	if obj.comments == nil {
+		return nil
+	}
+	comments := []*githubapi.IssueComment{}
+	for _, c := range obj.comments {
+		if c != comment {
+			comments = append(comments, c)
+		}
+	}
+	obj.comments = comments
+	return nil
+}
+
+// GetEvents returns all events on the issue, fetching and caching them
+// if they have not already been fetched this loop. The outcome of the
+// fetch is memoized for the life of the object - including a failed
+// fetch - so that the several consumers of events within a single
+// munge cost at most one listing.
+func (obj *MungeObject) GetEvents() ([]*githubapi.IssueEvent, bool) {
+	if obj.events != nil {
+		return obj.events, true
+	}
+	if obj.eventsErr {
+		return nil, false
+	}
+	obj.config.countAPICall("list-events")
+	if obj.config.client == nil {
+		obj.events = []*githubapi.IssueEvent{}
+		return obj.events, true
+	}
+	events := []*githubapi.IssueEvent{}
+	page := 1
+	for {
+		listOpts := &githubapi.ListOptions{PerPage: 100, Page: page}
+		result, response, err := obj.config.client.Issues.ListIssueEvents(context.Background(), obj.config.Org, obj.config.Project, obj.Number(), listOpts)
+		if err != nil {
+			glog.Errorf("Failed to list events for %d: %v", obj.Number(), err)
+			obj.eventsErr = true
+			return nil, false
+		}
+		events = append(events, result...)
+		if response.NextPage == 0 {
+			break
+		}
+		if page >= maxListPages {
+			glog.Errorf("Event listing for %d exceeds %d pages; treating as unavailable", obj.Number(), maxListPages)
+			obj.eventsErr = true
+			return nil, false
+		}
+		page = response.NextPage
+	}
+	obj.events = events
+	return events, true
+}
+
+// graphQLIssueQuery fetches everything the mungers routinely consume
+// about an issue - labels, milestone, comments and label/milestone
+// events - in a single request.
+const graphQLIssueQuery = `query($owner: String!, $name: String!, $number: Int!) {
+  repository(owner: $owner, name: $name) {
+    issue(number: $number) {
+      body
+      milestone { title }
+      labels(first: 100) { nodes { name } }
+      comments(first: 100) {
+        pageInfo { hasNextPage }
+        nodes { databaseId body createdAt url author { login } }
+      }
+      timelineItems(first: 100, itemTypes: [LABELED_EVENT, UNLABELED_EVENT, MILESTONED_EVENT, DEMILESTONED_EVENT]) {
+        pageInfo { hasNextPage }
+        nodes {
+          __typename
+          ... on LabeledEvent { createdAt actor { login } label { name } }
+          ... on UnlabeledEvent { createdAt actor { login } label { name } }
+          ... on MilestonedEvent { createdAt actor { login } milestoneTitle }
+          ... on DemilestonedEvent { createdAt actor { login } milestoneTitle }
+        }
+      }
+    }
+  }
+}`
+
+// graphQLEventNames maps GraphQL timeline item types to the event
+// names used by the REST events listing.
+var graphQLEventNames = map[string]string{
+	"LabeledEvent":      "labeled",
+	"UnlabeledEvent":    "unlabeled",
+	"MilestonedEvent":   "milestoned",
+	"DemilestonedEvent": "demilestoned",
+}
+
+// graphQLPageInfo carries the pagination state of a GraphQL
+// connection, so that a truncated listing can be detected.
+type graphQLPageInfo struct {
+	HasNextPage bool `json:"hasNextPage"`
+}
+
+// graphQLIssuePayload mirrors the subset of the GraphQL response that
+// the prefetch consumes.
+type graphQLIssuePayload struct {
+	Data struct {
+		Repository struct {
+			Issue struct {
+				Body      *string `json:"body"`
+				Milestone *struct {
+					Title string `json:"title"`
+				} `json:"milestone"`
+				Labels struct {
+					Nodes []struct {
+						Name string `json:"name"`
+					} `json:"nodes"`
+				} `json:"labels"`
+				Comments struct {
+					PageInfo graphQLPageInfo `json:"pageInfo"`
+					Nodes    []struct {
+						DatabaseID int64     `json:"databaseId"`
+						Body       string    `json:"body"`
+						CreatedAt  time.Time `json:"createdAt"`
+						URL        string    `json:"url"`
+						Author     *struct {
+							Login string `json:"login"`
+						} `json:"author"`
+					} `json:"nodes"`
+				} `json:"comments"`
+				TimelineItems struct {
+					PageInfo graphQLPageInfo `json:"pageInfo"`
+					Nodes    []struct {
+						Typename       string    `json:"__typename"`
+						CreatedAt      time.Time `json:"createdAt"`
+						MilestoneTitle string    `json:"milestoneTitle"`
+						Actor          *struct {
+							Login string `json:"login"`
+						} `json:"actor"`
+						Label *struct {
+							Name string `json:"name"`
+						} `json:"label"`
+					} `json:"nodes"`
+				} `json:"timelineItems"`
+			} `json:"issue"`
+		} `json:"repository"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// PrefetchGraphQL retrieves the issue's labels, milestone, comments
+// and label/milestone events in a single GraphQL request and seeds the
+// per-loop caches, replacing several REST round-trips per issue.
+// Returns false when the query failed, in which case the caches are
+// left untouched and the lazy REST fetches still apply.
+func (obj *MungeObject) PrefetchGraphQL() bool {
+	if obj.config.client == nil {
+		return false
+	}
+	obj.config.countAPICall("graphql")
+	payload := map[string]interface{}{
+		"query": graphQLIssueQuery,
+		"variables": map[string]interface{}{
+			"owner":  obj.config.Org,
+			"name":   obj.config.Project,
+			"number": obj.Number(),
+		},
+	}
+	request, err := obj.config.client.NewRequest("POST", "graphql", payload)
+	if err != nil {
+		glog.Errorf("Failed to build GraphQL request for %d: %v", obj.Number(), err)
+		return false
+	}
+	response := &graphQLIssuePayload{}
+	if _, err := obj.config.client.Do(context.Background(), request, response); err != nil {
+		glog.Errorf("GraphQL query for %d failed: %v", obj.Number(), err)
+		return false
+	}
+	if len(response.Errors) > 0 {
+		glog.Errorf("GraphQL query for %d returned an error: %s", obj.Number(), response.Errors[0].Message)
+		return false
+	}
+
+	issue := response.Data.Repository.Issue
+	// A truncated listing would hide the most recent activity - the
+	// anchor for notification and grace-period decisions - so fall back
+	// to the paginated REST fetches, mirroring the maxListPages policy.
+	if issue.Comments.PageInfo.HasNextPage || issue.TimelineItems.PageInfo.HasNextPage {
+		glog.Warningf("GraphQL prefetch for %d would truncate comments or events; falling back to REST", obj.Number())
+		return false
+	}
+	labels := []githubapi.Label{}
+	for _, node := range issue.Labels.Nodes {
+		name := node.Name
+		labels = append(labels, githubapi.Label{Name: &name})
+	}
+	obj.Issue.Labels = labels
+	obj.Issue.Body = issue.Body
+	if issue.Milestone != nil {
+		title := issue.Milestone.Title
+		obj.Issue.Milestone = &githubapi.Milestone{Title: &title}
+	} else {
+		obj.Issue.Milestone = nil
+	}
+
+	comments := []*githubapi.IssueComment{}
+	for _, node := range issue.Comments.Nodes {
+		id := node.DatabaseID
+		body := node.Body
+		createdAt := node.CreatedAt
+		url := node.URL
+		comment := &githubapi.IssueComment{
+			ID:        &id,
+			Body:      &body,
+			CreatedAt: &createdAt,
+			HTMLURL:   &url,
+		}
+		if node.Author != nil {
+			login := node.Author.Login
+			comment.User = &githubapi.User{Login: &login}
+		}
+		comments = append(comments, comment)
+	}
+	obj.comments = comments
+
+	events := []*githubapi.IssueEvent{}
+	for _, node := range issue.TimelineItems.Nodes {
+		eventName, known := graphQLEventNames[node.Typename]
+		if !known {
+			continue
+		}
+		name := eventName
+		createdAt := node.CreatedAt
+		event := &githubapi.IssueEvent{
+			Event:     &name,
+			CreatedAt: &createdAt,
+		}
+		if node.Actor != nil {
+			login := node.Actor.Login
+			event.Actor = &githubapi.User{Login: &login}
+		}
+		if node.Label != nil {
+			labelName := node.Label.Name
+			event.Label = &githubapi.Label{Name: &labelName}
+		}
+		if node.MilestoneTitle != "" {
+			title := node.MilestoneTitle
+			event.Milestone = &githubapi.Milestone{Title: &title}
+		}
+		events = append(events, event)
+	}
+	obj.events = events
+	obj.eventsErr = false
+	return true
+}
+
+// issueTypeResponse captures the subset of the issue payload that the
+// vendored go-github release does not decode.
+type issueTypeResponse struct {
+	Type *struct {
+		Name string `json:"name"`
+	} `json:"type"`
+}
+
+// IssueType returns the name of the issue's native github issue type,
+// or the empty string if the issue has none. The vendored go-github
+// release predates issue types, so the value is fetched directly from
+// the API and cached for the loop.
+func (obj *MungeObject) IssueType() (string, bool) {
+	if obj.issueType != nil {
+		return *obj.issueType, true
+	}
+	if obj.config.client == nil {
+		empty := ""
+		obj.issueType = &empty
+		return "", true
+	}
+	obj.config.countAPICall("get-issue-type")
+	request, err := obj.config.client.NewRequest("GET", fmt.Sprintf("repos/%s/%s/issues/%d", obj.config.Org, obj.config.Project, obj.Number()), nil)
+	if err != nil {
+		glog.Errorf("Failed to build issue type request for %d: %v", obj.Number(), err)
+		return "", false
+	}
+	response := &issueTypeResponse{}
+	if _, err := obj.config.client.Do(context.Background(), request, response); err != nil {
+		glog.Errorf("Failed to fetch issue type for %d: %v", obj.Number(), err)
+		return "", false
+	}
+	name := ""
+	if response.Type != nil {
+		name = response.Type.Name
+	}
+	obj.issueType = &name
+	return name, true
+}
+
+// SetIssueType primes the issue-type cache, for use in tests.
+func (obj *MungeObject) SetIssueType(name string) {
+	obj.issueType = &name
+}
+
+// GetTimeline returns all timeline entries for the issue, fetching and
+// caching them if they have not already been fetched this loop.
+func (obj *MungeObject) GetTimeline() ([]*githubapi.Timeline, bool) {
+	if obj.timeline != nil {
+		return obj.timeline, true
+	}
+	if obj.config.client == nil {
+		obj.timeline = []*githubapi.Timeline{}
+		return obj.timeline, true
+	}
+	obj.config.countAPICall("list-timeline")
+	timeline := []*githubapi.Timeline{}
+	page := 1
+	for {
+		listOpts := &githubapi.ListOptions{PerPage: 100, Page: page}
+		result, response, err := obj.config.client.Issues.ListIssueTimeline(context.Background(), obj.config.Org, obj.config.Project, obj.Number(), listOpts)
+		if err != nil {
+			glog.Errorf("Failed to list timeline for %d: %v", obj.Number(), err)
+			return nil, false
+		}
+		timeline = append(timeline, result...)
+		if response.NextPage == 0 {
+			break
+		}
+		if page >= maxListPages {
+			glog.Errorf("Timeline listing for %d exceeds %d pages; treating as unavailable", obj.Number(), maxListPages)
+			return nil, false
+		}
+		page = response.NextPage
+	}
+	obj.timeline = timeline
+	return timeline, true
+}
+
+// HasOpenLinkedPR indicates whether an open pull request in the same
+// repository references the issue, according to the issue's timeline.
+func (obj *MungeObject) HasOpenLinkedPR() (bool, bool) {
+	prs, ok := obj.OpenLinkedPRs()
+	return len(prs) > 0, ok
+}
+
+// OpenLinkedPRs returns the numbers of the open pull requests in the
+// same repository that reference the issue, according to the issue's
+// timeline.
+func (obj *MungeObject) OpenLinkedPRs() ([]int, bool) {
+	timeline, ok := obj.GetTimeline()
+	if !ok {
+		return nil, false
+	}
+	prs := []int{}
+	seen := map[int]bool{}
+	for _, entry := range timeline {
+		if entry.Event == nil || *entry.Event != "cross-referenced" {
+			continue
+		}
+		if entry.Source == nil || entry.Source.URL == nil {
+			continue
+		}
+		number, ok := obj.issueNumberFromURL(*entry.Source.URL)
+		if !ok || seen[number] {
+			continue
+		}
+		seen[number] = true
+		ref, found := obj.referencedIssue(number)
+		if !found {
+			return nil, false
+		}
+		if ref != nil && ref.IsPullRequest() && ref.State != nil && *ref.State == "open" {
+			prs = append(prs, number)
+		}
+	}
+	return prs, true
+}
+
+// HasOpenLinkedPRToBranch indicates whether an open pull request
+// referencing the issue targets the given base branch.
+func (obj *MungeObject) HasOpenLinkedPRToBranch(branch string) (bool, bool) {
+	prs, ok := obj.OpenLinkedPRs()
+	if !ok {
+		return false, false
+	}
+	for _, number := range prs {
+		base, ok := obj.prBaseBranch(number)
+		if !ok {
+			return false, false
+		}
+		if base == branch {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// prBaseBranch returns the base branch of the given pull request,
+// fetching and caching it if it has not already been fetched this
+// loop.
+func (obj *MungeObject) prBaseBranch(number int) (string, bool) {
+	if branch, ok := obj.prBases[number]; ok {
+		return branch, true
+	}
+	if obj.config.client == nil {
+		return "", true
+	}
+	obj.config.countAPICall("get-pr")
+	pr, _, err := obj.config.client.PullRequests.Get(context.Background(), obj.config.Org, obj.config.Project, number)
+	if err != nil {
+		glog.Errorf("Failed to get pull request %d: %v", number, err)
+		return "", false
+	}
+	branch := ""
+	if pr.Base != nil && pr.Base.Ref != nil {
+		branch = *pr.Base.Ref
+	}
+	if obj.prBases == nil {
+		obj.prBases = map[int]string{}
+	}
+	obj.prBases[number] = branch
+	return branch, true
+}
+
+// SetPRBaseBranch primes the base-branch cache for a linked pull
+// request, for use in tests.
+func (obj *MungeObject) SetPRBaseBranch(number int, branch string) {
+	if obj.prBases == nil {
+		obj.prBases = map[int]string{}
+	}
+	obj.prBases[number] = branch
+}
+
+// PRReviews returns the reviews of the given pull request, fetching
+// and caching them if they have not already been fetched this loop.
+func (obj *MungeObject) PRReviews(number int) ([]*githubapi.PullRequestReview, bool) {
+	if reviews, ok := obj.prReviews[number]; ok {
+		return reviews, true
+	}
+	if obj.config.client == nil {
+		return nil, true
+	}
+	obj.config.countAPICall("list-reviews")
+	reviews, _, err := obj.config.client.PullRequests.ListReviews(context.Background(), obj.config.Org, obj.config.Project, number, &githubapi.ListOptions{PerPage: 100})
+	if err != nil {
+		glog.Errorf("Failed to list reviews for pull request %d: %v", number, err)
+		return nil, false
+	}
+	if obj.prReviews == nil {
+		obj.prReviews = map[int][]*githubapi.PullRequestReview{}
+	}
+	obj.prReviews[number] = reviews
+	return reviews, true
+}
+
+// SetPRReviews primes the review cache for a linked pull request, for
+// use in tests.
+func (obj *MungeObject) SetPRReviews(number int, reviews []*githubapi.PullRequestReview) {
+	if obj.prReviews == nil {
+		obj.prReviews = map[int][]*githubapi.PullRequestReview{}
+	}
+	obj.prReviews[number] = reviews
+}
+
+// issueNumberFromURL extracts the issue number from an API URL of the
+// form .../repos/<org>/<project>/issues/<number>, rejecting references
+// to other repositories.
+func (obj *MungeObject) issueNumberFromURL(rawURL string) (int, bool) {
+	prefix := fmt.Sprintf("repos/%s/%s/issues/", obj.config.Org, obj.config.Project)
+	index := strings.Index(rawURL, prefix)
+	if index < 0 {
+		return 0, false
+	}
+	number, err := strconv.Atoi(rawURL[index+len(prefix):])
+	if err != nil {
+		return 0, false
+	}
+	return number, true
+}
+
+// referencedIssue returns the referenced issue with the given number,
+// fetching and caching it if it has not already been fetched this
+// loop. A nil issue with found=true means the reference could not be
+// resolved from the cache alone.
+func (obj *MungeObject) referencedIssue(number int) (*githubapi.Issue, bool) {
+	if ref, ok := obj.referenced[number]; ok {
+		return ref, true
+	}
+	if obj.config.client == nil {
+		return nil, true
+	}
+	obj.config.countAPICall("get-issue")
+	issue, _, err := obj.config.client.Issues.Get(context.Background(), obj.config.Org, obj.config.Project, number)
+	if err != nil {
+		glog.Errorf("Failed to get referenced issue %d: %v", number, err)
+		return nil, false
+	}
+	if obj.referenced == nil {
+		obj.referenced = map[int]*githubapi.Issue{}
+	}
+	obj.referenced[number] = issue
+	return issue, true
+}
+
+// CloseIssue closes the issue.
+func (obj *MungeObject) CloseIssue() error {
+	obj.config.countAPICall("close-issue")
+	glog.Infof("Closing issue %d", obj.Number())
+	closed := "closed"
+	if !obj.config.DryRun {
+		request := &githubapi.IssueRequest{State: &closed}
+		if _, _, err := obj.config.client.Issues.Edit(context.Background(), obj.config.Org, obj.config.Project, obj.Number(), request); err != nil {
+			return err
+		}
+	}
+	obj.Issue.State = &closed
+	return nil
+}
+
+// projectCardForIssue returns the card for this issue in the given
+// project column, or nil if the column holds no card for it.
+func (obj *MungeObject) projectCardForIssue(columnID int64) (*githubapi.ProjectCard, bool) {
+	if obj.config.client == nil {
+		return nil, true
+	}
+	obj.config.countAPICall("list-project-cards")
+	suffix := fmt.Sprintf("/issues/%d", obj.Number())
+	page := 1
+	for {
+		listOpts := &githubapi.ProjectCardListOptions{
+			ListOptions: githubapi.ListOptions{PerPage: 100, Page: page},
+		}
+		cards, response, err := obj.config.client.Projects.ListProjectCards(context.Background(), columnID, listOpts)
+		if err != nil {
+			glog.Errorf("Failed to list cards for column %d: %v", columnID, err)
+			return nil, false
+		}
+		for _, card := range cards {
+			if card.ContentURL != nil && strings.HasSuffix(*card.ContentURL, suffix) {
+				return card, true
+			}
+		}
+		if response.NextPage == 0 {
+			break
+		}
+		if page >= maxListPages {
+			glog.Errorf("Card listing for column %d exceeds %d pages; treating as unavailable", columnID, maxListPages)
+			return nil, false
+		}
+		page = response.NextPage
+	}
+	return nil, true
+}
+
+// AddToProjectColumn adds a card for the issue to the given project
+// board column if the column does not already hold one.
+func (obj *MungeObject) AddToProjectColumn(columnID int64) error {
+	card, ok := obj.projectCardForIssue(columnID)
+	if !ok {
+		return fmt.Errorf("failed to list cards for column %d", columnID)
+	}
+	if card != nil {
+		return nil
+	}
+	obj.config.countAPICall("create-project-card")
+	glog.Infof("Adding %d to project column %d", obj.Number(), columnID)
+	if obj.config.DryRun || obj.config.client == nil {
+		return nil
+	}
+	if obj.Issue.ID == nil {
+		return fmt.Errorf("issue %d has no id to associate with a card", obj.Number())
+	}
+	cardOpts := &githubapi.ProjectCardOptions{
+		ContentID:   *obj.Issue.ID,
+		ContentType: "Issue",
+	}
+	_, _, err := obj.config.client.Projects.CreateProjectCard(context.Background(), columnID, cardOpts)
+	return err
+}
+
+// RemoveFromProjectColumn deletes the issue's card from the given
+// project board column if the column holds one.
+func (obj *MungeObject) RemoveFromProjectColumn(columnID int64) error {
+	card, ok := obj.projectCardForIssue(columnID)
+	if !ok {
+		return fmt.Errorf("failed to list cards for column %d", columnID)
+	}
+	if card == nil || card.ID == nil {
+		return nil
+	}
+	obj.config.countAPICall("delete-project-card")
+	glog.Infof("Removing %d from project column %d", obj.Number(), columnID)
+	if obj.config.DryRun || obj.config.client == nil {
+		return nil
+	}
+	_, err := obj.config.client.Projects.DeleteProjectCard(context.Background(), *card.ID)
+	return err
+}
+
+func timePtr(t time.Time) *time.Time { return &t }
@@ -0,0 +1,64 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package github_test provides helpers for constructing MungeObjects
+// backed by static data instead of the github API.
+package github_test
+
+import (
+	"time"
+
+	githubapi "github.com/google/go-github/github"
+
+	"k8s.io/test-infra/mungegithub/github"
+)
+
+// Issue returns a minimal issue with the given user, number, labels and
+// open/closed state.
+func Issue(user string, number int, labels []string, isPR bool) *githubapi.Issue {
+	issue := &githubapi.Issue{
+		ID:        int64Ptr(1),
+		Number:    intPtr(number),
+		User:      &githubapi.User{Login: strPtr(user)},
+		State:     strPtr("open"),
+		CreatedAt: timePtr(time.Unix(0, 0)),
+	}
+	if isPR {
+		issue.PullRequestLinks = &githubapi.PullRequestLinks{}
+	}
+	for _, label := range labels {
+		issue.Labels = append(issue.Labels, githubapi.Label{Name: strPtr(label)})
+	}
+	return issue
+}
+
+// NewTestObject returns a MungeObject in dry-run mode whose comment and
+// event caches are pre-populated with the provided data. Mutations made
+// by mungers are applied to the local caches only.
+func NewTestObject(issue *githubapi.Issue, comments []*githubapi.IssueComment, events []*githubapi.IssueEvent) *github.MungeObject {
+	config := &github.Config{
+		Org:     "org",
+		Project: "project",
+		BotName: "bot",
+		DryRun:  true,
+	}
+	return github.NewTestObject(config, issue, comments, events)
+}
+
+func intPtr(val int) *int            { return &val }
+func int64Ptr(val int64) *int64      { return &val }
+func strPtr(val string) *string      { return &val }
+func timePtr(t time.Time) *time.Time { return &t }
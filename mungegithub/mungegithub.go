@@ -0,0 +1,141 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// mungegithub is a server that runs the configured mungers against the
+// issues of a github repository on a polling loop.
+package main
+
+import (
+	"flag"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+
+	"k8s.io/test-infra/mungegithub/features"
+	"k8s.io/test-infra/mungegithub/github"
+	"k8s.io/test-infra/mungegithub/mungers"
+	"k8s.io/test-infra/mungegithub/options"
+)
+
+func main() {
+	config := &github.Config{}
+	var mungerNames string
+	var optionsFile string
+	var tokenFile string
+	var address string
+	var period time.Duration
+
+	flag.StringVar(&config.Org, "organization", "", "The github organization to scan")
+	flag.StringVar(&config.Project, "project", "", "The github project to scan")
+	flag.StringVar(&config.BotName, "bot-name", "", "The login of the user the token belongs to")
+	flag.StringVar(&config.Endpoint, "endpoint", "", "The github API base URL, for github enterprise installs")
+	flag.BoolVar(&config.DryRun, "dry-run", true, "If true, no mutations will be made to github")
+	flag.StringVar(&tokenFile, "token-file", "", "The file containing the github oauth token")
+	flag.StringVar(&mungerNames, "mungers", "", "A comma separated list of mungers to run")
+	flag.StringVar(&optionsFile, "options-file", "", "A file containing dynamic option values, reloaded every loop")
+	flag.StringVar(&address, "address", ":8080", "The address to serve status endpoints on")
+	var sigMembershipFile string
+	flag.StringVar(&sigMembershipFile, "sig-membership-file", "", "A file recording the members of each sig, one 'sig-name=member1,member2' line per sig")
+	var areaApproversFile string
+	flag.StringVar(&areaApproversFile, "area-approvers-file", "", "A file recording the OWNERS approvers of each area, one 'area-name=approver1,approver2' line per area")
+	flag.DurationVar(&period, "period", 10*time.Minute, "The period between munge loops")
+	flag.Parse()
+
+	if tokenFile != "" {
+		data, err := os.ReadFile(tokenFile)
+		if err != nil {
+			glog.Fatalf("Unable to read token file %q: %v", tokenFile, err)
+		}
+		config.Token = strings.TrimSpace(string(data))
+	}
+
+	activeMungers, err := mungers.GetActiveMungers(strings.Split(mungerNames, ","))
+	if err != nil {
+		glog.Fatalf("Unable to find requested mungers: %v", err)
+	}
+
+	opts := options.New()
+	mungers.RegisterOptions(opts, activeMungers)
+	loadOptions(opts, optionsFile)
+
+	if err := config.PreExecute(); err != nil {
+		glog.Fatalf("Unable to initialize github config: %v", err)
+	}
+
+	feats := features.NewFeatures()
+	if sigMembershipFile != "" {
+		if err := feats.SigMembership.LoadFromFile(sigMembershipFile); err != nil {
+			glog.Fatalf("Unable to load sig membership: %v", err)
+		}
+	}
+	if areaApproversFile != "" {
+		if err := feats.AreaApprovers.LoadFromFile(areaApproversFile); err != nil {
+			glog.Fatalf("Unable to load area approvers: %v", err)
+		}
+	}
+	if err := feats.Initialize(config, mungers.RequestedFeatures(activeMungers)); err != nil {
+		glog.Fatalf("Unable to initialize features: %v", err)
+	}
+
+	if err := mungers.InitializeMungers(activeMungers, config, feats); err != nil {
+		glog.Fatalf("Unable to initialize mungers: %v", err)
+	}
+
+	go func() {
+		glog.Fatal(http.ListenAndServe(address, nil))
+	}()
+
+	for {
+		nextLoop := time.Now().Add(period)
+
+		loadOptions(opts, optionsFile)
+		if err := feats.EachLoop(); err != nil {
+			glog.Errorf("Error from feature loop: %v", err)
+		}
+		if err := mungers.EachLoop(activeMungers); err != nil {
+			glog.Errorf("Error from munger loop, skipping this loop: %v", err)
+		} else if err := config.ForEachIssueDo(func(obj *github.MungeObject) error {
+			mungers.MungeIssue(activeMungers, obj)
+			return nil
+		}); err != nil {
+			glog.Errorf("Error munging issues: %v", err)
+		}
+
+		if sleep := time.Until(nextLoop); sleep > 0 {
+			glog.Infof("Sleeping %v until next loop", sleep)
+			time.Sleep(sleep)
+		}
+	}
+}
+
+// loadOptions reloads dynamic option values from the given file, if
+// one was configured.
+func loadOptions(opts *options.Options, optionsFile string) {
+	if optionsFile == "" {
+		return
+	}
+	data, err := os.ReadFile(optionsFile)
+	if err != nil {
+		glog.Errorf("Unable to read options file %q: %v", optionsFile, err)
+		return
+	}
+	if changed := opts.PopulateFromString(string(data)); changed.Len() > 0 {
+		glog.Infof("Options changed: %v", changed.List())
+	}
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package approvers provides helpers for rendering munger messages.
+package approvers
+
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/golang/glog"
+)
+
+// GenerateTemplate renders the given template with the given data.
+func GenerateTemplate(templ, name string, data interface{}) (string, error) {
+	buf := bytes.NewBufferString("")
+	messageTempl, err := template.New(name).Parse(templ)
+	if err != nil {
+		return "", err
+	}
+	if err := messageTempl.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// GenerateTemplateOrFail renders the given template with the given
+// data, logging an error and returning nil if rendering fails.
+func GenerateTemplateOrFail(templ, name string, data interface{}) *string {
+	message, err := GenerateTemplate(templ, name, data)
+	if err != nil {
+		glog.Errorf("Failed to render template %q: %v", name, err)
+		return nil
+	}
+	return &message
+}
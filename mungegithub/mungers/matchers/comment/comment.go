@@ -0,0 +1,106 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package comment provides a unified representation of issue comments
+// along with matchers to filter them.
+package comment
+
+import (
+	"time"
+
+	githubapi "github.com/google/go-github/github"
+)
+
+// Comment is a unified, simplified representation of a github comment.
+type Comment struct {
+	Body      *string
+	Author    *string
+	CreatedAt *time.Time
+
+	// Source is the underlying github object the comment was created
+	// from, to allow mutation of the original.
+	Source interface{}
+}
+
+// FromIssueComment converts a github issue comment to a Comment.
+func FromIssueComment(comment *githubapi.IssueComment) *Comment {
+	if comment == nil {
+		return nil
+	}
+	var author *string
+	if comment.User != nil {
+		author = comment.User.Login
+	}
+	return &Comment{
+		Body:      comment.Body,
+		Author:    author,
+		CreatedAt: comment.CreatedAt,
+		Source:    comment,
+	}
+}
+
+// FromIssueComments converts a list of github issue comments to a list
+// of Comments.
+func FromIssueComments(comments []*githubapi.IssueComment) []*Comment {
+	list := []*Comment{}
+	for _, comment := range comments {
+		list = append(list, FromIssueComment(comment))
+	}
+	return list
+}
+
+// Matcher matches a comment.
+type Matcher interface {
+	Match(comment *Comment) bool
+}
+
+// FilteredComments is the result of filtering a list of comments.
+type FilteredComments []*Comment
+
+// FilterComments returns the comments that match the given matcher, in
+// their original order.
+func FilterComments(comments []*Comment, matcher Matcher) FilteredComments {
+	filtered := FilteredComments{}
+	for _, comment := range comments {
+		if matcher.Match(comment) {
+			filtered = append(filtered, comment)
+		}
+	}
+	return filtered
+}
+
+// GetLast returns the most recent comment in the list, or nil if the
+// list is empty.
+func (f FilteredComments) GetLast() *Comment {
+	if len(f) == 0 {
+		return nil
+	}
+	return f[len(f)-1]
+}
+
+// GetFirst returns the oldest comment in the list, or nil if the list
+// is empty.
+func (f FilteredComments) GetFirst() *Comment {
+	if len(f) == 0 {
+		return nil
+	}
+	return f[0]
+}
+
+// Empty returns whether the list contains no comments.
+func (f FilteredComments) Empty() bool {
+	return len(f) == 0
+}
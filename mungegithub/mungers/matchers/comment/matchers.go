@@ -0,0 +1,136 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package comment
+
+import (
+	"strings"
+	"time"
+)
+
+// CreatedAfter matches comments created after the given time.
+type CreatedAfter time.Time
+
+// Match returns true if the comment is created after the time.
+func (c CreatedAfter) Match(comment *Comment) bool {
+	if comment == nil || comment.CreatedAt == nil {
+		return false
+	}
+	return comment.CreatedAt.After(time.Time(c))
+}
+
+// CreatedBefore matches comments created before the given time.
+type CreatedBefore time.Time
+
+// Match returns true if the comment is created before the time.
+func (c CreatedBefore) Match(comment *Comment) bool {
+	if comment == nil || comment.CreatedAt == nil {
+		return false
+	}
+	return comment.CreatedAt.Before(time.Time(c))
+}
+
+// AuthorLogin matches comments made by the given author.
+type AuthorLogin string
+
+// Match returns true if the comment author matches the login.
+func (a AuthorLogin) Match(comment *Comment) bool {
+	if comment == nil || comment.Author == nil {
+		return false
+	}
+	return strings.EqualFold(*comment.Author, string(a))
+}
+
+// AuthorLogins matches comments made by any of the given authors,
+// e.g. a bot's current and legacy logins.
+type AuthorLogins []string
+
+// Match returns true if the comment author matches any of the logins.
+func (a AuthorLogins) Match(comment *Comment) bool {
+	for _, login := range a {
+		if AuthorLogin(login).Match(comment) {
+			return true
+		}
+	}
+	return false
+}
+
+// And matches comments that match all of its sub-matchers.
+type And []Matcher
+
+// Match returns true if all sub-matchers match the comment.
+func (a And) Match(comment *Comment) bool {
+	for _, matcher := range a {
+		if !matcher.Match(comment) {
+			return false
+		}
+	}
+	return true
+}
+
+// Or matches comments that match any of its sub-matchers.
+type Or []Matcher
+
+// Match returns true if any sub-matcher matches the comment.
+func (o Or) Match(comment *Comment) bool {
+	for _, matcher := range o {
+		if matcher.Match(comment) {
+			return true
+		}
+	}
+	return false
+}
+
+// Command matches comments whose first line is exactly the given
+// slash command.
+type Command string
+
+// Match returns true if the comment issues the command.
+func (c Command) Match(comment *Comment) bool {
+	if comment == nil || comment.Body == nil {
+		return false
+	}
+	firstLine := strings.SplitN(strings.TrimSpace(*comment.Body), "\n", 2)[0]
+	return strings.TrimSpace(firstLine) == string(c)
+}
+
+// MungerNotificationName matches notification comments posted by the
+// given bot with the given notification name.
+func MungerNotificationName(notif, botName string) Matcher {
+	return MungerNotificationNames(notif, []string{botName})
+}
+
+// MungerNotificationNames matches notification comments posted by any
+// of the given bot logins with the given notification name.
+func MungerNotificationNames(notif string, botNames []string) Matcher {
+	return And([]Matcher{
+		AuthorLogins(botNames),
+		notificationName(notif),
+	})
+}
+
+// notificationName matches comments whose body parses as a notification
+// with the given name.
+type notificationName string
+
+// Match returns true if the comment is a notification with this name.
+func (n notificationName) Match(comment *Comment) bool {
+	notification := ParseNotification(comment)
+	if notification == nil {
+		return false
+	}
+	return strings.EqualFold(notification.Name, string(n))
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package comment
+
+import (
+	"strings"
+
+	"k8s.io/test-infra/mungegithub/github"
+)
+
+// Notification is a bot comment whose first line is an upper-case
+// notification name, optionally followed by arguments on the same line
+// and free-form context on subsequent lines.
+type Notification struct {
+	Name      string
+	Arguments string
+	Context   string
+}
+
+// NewNotification creates a notification with the given name, arguments
+// and context.
+func NewNotification(name, arguments, context string) *Notification {
+	return &Notification{
+		Name:      name,
+		Arguments: strings.TrimSpace(arguments),
+		Context:   strings.TrimSpace(context),
+	}
+}
+
+// ParseNotification attempts to parse the given comment as a
+// notification. Returns nil if the comment is not a notification.
+func ParseNotification(comment *Comment) *Notification {
+	if comment == nil || comment.Body == nil {
+		return nil
+	}
+	body := strings.TrimSpace(*comment.Body)
+
+	lines := strings.SplitN(body, "\n", 2)
+	firstLine := strings.TrimSpace(lines[0])
+	context := ""
+	if len(lines) > 1 {
+		context = strings.TrimSpace(lines[1])
+	}
+
+	fields := strings.SplitN(firstLine, " ", 2)
+	name := fields[0]
+	arguments := ""
+	if len(fields) > 1 {
+		arguments = strings.TrimSpace(fields[1])
+	}
+
+	// Older versions of the munger wrapped the notification name in
+	// square brackets. Recognizing the legacy form keeps existing
+	// comments current across an upgrade instead of recreating them.
+	if strings.HasPrefix(name, "[") && strings.HasSuffix(name, "]") {
+		name = name[1 : len(name)-1]
+	}
+
+	// The name of a notification is upper-case by convention, to make
+	// bot comments easy to distinguish from human ones.
+	if name == "" || name != strings.ToUpper(name) {
+		return nil
+	}
+
+	return &Notification{
+		Name:      name,
+		Arguments: arguments,
+		Context:   context,
+	}
+}
+
+// String returns the comment body representation of the notification.
+func (n *Notification) String() string {
+	str := strings.ToUpper(n.Name)
+	if n.Arguments != "" {
+		str += " " + n.Arguments
+	}
+	if n.Context != "" {
+		str += "\n\n" + n.Context
+	}
+	return str
+}
+
+// Post writes the notification as a comment on the given object.
+func (n *Notification) Post(obj *github.MungeObject) error {
+	return obj.WriteComment(n.String())
+}
+
+// Equal compares this notification to the given notification for
+// equivalence. Name comparison is case-insensitive.
+func (n *Notification) Equal(other *Notification) bool {
+	if other == nil {
+		return false
+	}
+	return strings.EqualFold(n.Name, other.Name) &&
+		n.Arguments == other.Arguments &&
+		n.Context == other.Context
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package event provides matchers to filter github issue events.
+package event
+
+import (
+	"strings"
+
+	githubapi "github.com/google/go-github/github"
+)
+
+// Matcher matches an issue event.
+type Matcher interface {
+	Match(event *githubapi.IssueEvent) bool
+}
+
+// Events is a filtered list of issue events.
+type Events []*githubapi.IssueEvent
+
+// FilterEvents returns the events that match the given matcher, in
+// their original order.
+func FilterEvents(events []*githubapi.IssueEvent, matcher Matcher) Events {
+	filtered := Events{}
+	for _, event := range events {
+		if matcher.Match(event) {
+			filtered = append(filtered, event)
+		}
+	}
+	return filtered
+}
+
+// GetLast returns the most recent event in the list, or nil if the list
+// is empty.
+func (e Events) GetLast() *githubapi.IssueEvent {
+	if len(e) == 0 {
+		return nil
+	}
+	return e[len(e)-1]
+}
+
+// And matches events that match all of its sub-matchers.
+type And []Matcher
+
+// Match returns true if all sub-matchers match the event.
+func (a And) Match(event *githubapi.IssueEvent) bool {
+	for _, matcher := range a {
+		if !matcher.Match(event) {
+			return false
+		}
+	}
+	return true
+}
+
+// AddLabel matches "labeled" events.
+type AddLabel struct{}
+
+// Match returns true if the event is a label addition.
+func (a AddLabel) Match(event *githubapi.IssueEvent) bool {
+	return event != nil && event.Event != nil && *event.Event == "labeled"
+}
+
+// RemoveLabel matches "unlabeled" events.
+type RemoveLabel struct{}
+
+// Match returns true if the event is a label removal.
+func (r RemoveLabel) Match(event *githubapi.IssueEvent) bool {
+	return event != nil && event.Event != nil && *event.Event == "unlabeled"
+}
+
+// LabelName matches label events for the given label.
+type LabelName string
+
+// Match returns true if the event's label has this name.
+func (l LabelName) Match(event *githubapi.IssueEvent) bool {
+	if event == nil || event.Label == nil || event.Label.Name == nil {
+		return false
+	}
+	return *event.Label.Name == string(l)
+}
+
+// LabelPrefix matches label events whose label has the given prefix.
+type LabelPrefix string
+
+// Match returns true if the event's label name has this prefix.
+func (l LabelPrefix) Match(event *githubapi.IssueEvent) bool {
+	if event == nil || event.Label == nil || event.Label.Name == nil {
+		return false
+	}
+	return strings.HasPrefix(*event.Label.Name, string(l))
+}
+
+// Actor matches events performed by the given user.
+type Actor string
+
+// Match returns true if the event actor matches the login.
+func (a Actor) Match(event *githubapi.IssueEvent) bool {
+	if event == nil || event.Actor == nil || event.Actor.Login == nil {
+		return false
+	}
+	return strings.EqualFold(*event.Actor.Login, string(a))
+}
+
+// Actors matches events performed by any of the given users, e.g. a
+// bot's current and legacy logins.
+type Actors []string
+
+// Match returns true if the event actor matches any of the logins.
+func (a Actors) Match(event *githubapi.IssueEvent) bool {
+	for _, login := range a {
+		if Actor(login).Match(event) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,4136 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mungers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/smtp"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	githubapi "github.com/google/go-github/github"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/test-infra/mungegithub/features"
+	"k8s.io/test-infra/mungegithub/github"
+	"k8s.io/test-infra/mungegithub/mungers/approvers"
+	c "k8s.io/test-infra/mungegithub/mungers/matchers/comment"
+	"k8s.io/test-infra/mungegithub/mungers/matchers/event"
+	"k8s.io/test-infra/mungegithub/mungers/mungerutil"
+	"k8s.io/test-infra/mungegithub/options"
+)
+
+type milestoneState int
+
+// milestoneStateConfig defines the label and notification configuration
+// for a given milestone state.
+type milestoneStateConfig struct {
+	// The label to apply to an issue in this state
+	label string
+	// The title of the notification message. May be a template
+	// expanded with the same arguments as the notification body.
+	title string
+	// Whether the notification should be repeated on the configured interval
+	warnOnInterval bool
+	// Whether sigs should be mentioned in the notification message
+	notifySIGs bool
+}
+
+const (
+	day = time.Hour * 24
+
+	milestoneNotifierName = "MilestoneNotifier"
+
+	milestoneResolvedTitle = "Milestone Issue **Resolved**"
+
+	milestoneModeDev    = "dev"
+	milestoneModeSlush  = "slush"
+	milestoneModeFreeze = "freeze"
+
+	milestoneRemovalModeClear     = "clear"
+	milestoneRemovalModeLabelOnly = "label-only"
+
+	milestoneLabelsIncompleteLabel = "milestone/incomplete-labels"
+	milestoneNeedsApprovalLabel    = "milestone/needs-approval"
+	milestoneAtRiskLabel           = "milestone/at-risk"
+	milestoneNeedsAttentionLabel   = "milestone/needs-attention"
+	milestoneNeedsTriageLabel      = "milestone/needs-triage"
+	milestoneRemovedLabel          = "milestone/removed"
+	milestoneStaleLabel            = "milestone/stale"
+
+	statusApprovedLabel   = "status/approved-for-milestone"
+	statusInProgressLabel = "status/in-progress"
+
+	blockerLabel = "priority/critical-urgent"
+
+	featureKindLabel = "kind/feature"
+
+	sigLabelPrefix            = "sig/"
+	areaLabelPrefix           = "area/"
+	defaultSigMentionTemplate = "@kubernetes/sig-%s-misc"
+	defaultHelpBaseURL        = "https://github.com/kubernetes/community/blob/master"
+
+	milestoneInProgressCommand      = "/milestone status in-progress"
+	milestoneRequestApprovalCommand = "/milestone request-approval"
+	milestoneApproveCommand         = "/approve-for-milestone"
+	milestoneRestoreCommand         = "/milestone restore"
+	milestoneRemoveCommand          = "/milestone remove"
+	milestoneStatusCommand          = "/milestone status"
+	milestonePriorityCommand        = "/priority"
+
+	priorityLabelPrefix = "priority/"
+
+	milestoneApprovalRequestNotifierName = "MilestoneApprovalRequest"
+	milestoneStatusQueryNotifierName     = "MilestoneStatusQuery"
+
+	milestoneBlackoutDateFormat = "2006-01-02"
+
+	// defaultETAPattern matches the ETA line suggested by the update
+	// interval warning's example.
+	defaultETAPattern = `(?mi)^\s*ETA\s*[:=]`
+
+	// defaultDesignDocPattern matches the usual places kubernetes
+	// design references live.
+	defaultDesignDocPattern = `(?i)(docs\.google\.com/document|design-proposals|/keps/|/enhancements/)`
+
+	// defaultSnoozeLabelPrefix prefixes labels of the form
+	// milestone/snooze-until-2006-01-02 that suspend munging of an
+	// issue until the embedded date.
+	defaultSnoozeLabelPrefix = "milestone/snooze-until-"
+
+	// milestoneRemovalReasonMarker embeds - in an html comment invisible
+	// to readers - a machine-readable code for why an issue was removed
+	// from the milestone.
+	milestoneRemovalReasonMarker           = "<!-- milestone-removal-reason: %s -->"
+	milestoneRemovalReasonUnapproved       = "unapproved"
+	milestoneRemovalReasonIncompleteLabels = "incomplete-labels"
+	milestoneRemovalReasonNonBlocker       = "non-blocker-freeze"
+	milestoneRemovalReasonByRequest        = "by-request"
+
+	// milestoneFinalRemovalComment explains the closing of an issue
+	// removed from the final milestone of a release.
+	milestoneFinalRemovalComment = "This issue was removed from the final %s milestone and has been closed. If it is still relevant, please reopen it and triage it for the next release."
+
+	// milestoneDefaultPriorityComment notes the automatic application
+	// of the configured default priority label.
+	milestoneDefaultPriorityComment = "No priority label was set, so the default priority **%s** has been applied. If this is incorrect, please apply the appropriate `priority/` label."
+
+	// milestoneNotificationKeyMarker embeds - in an html comment
+	// invisible to readers - an idempotency key derived from the
+	// notification's state and content, so that a racing loop can
+	// detect an already-posted identical notification.
+	milestoneNotificationKeyMarker = "<!-- milestone-notification-key: %s -->"
+
+	milestoneOptActiveMilestone      = "active-milestone"
+	milestoneOptMode                 = "milestone-mode"
+	milestoneOptAutoReaddBlockers    = "milestone-auto-readd-blockers"
+	milestoneOptRequiredLabelGroups  = "milestone-required-label-groups"
+	milestoneOptWarningInterval      = "milestone-warning-interval"
+	milestoneOptWarningBackoff       = "milestone-warning-backoff"
+	milestoneOptWarningBackoffCap    = "milestone-warning-backoff-cap"
+	milestoneOptLabelGracePeriod     = "milestone-label-grace-period"
+	milestoneOptGracePeriodFloor     = "milestone-grace-period-floor"
+	milestoneOptApprovalGracePeriod  = "milestone-approval-grace-period"
+	milestoneOptSlushUpdateInterval  = "milestone-slush-update-interval"
+	milestoneOptFreezeUpdateInterval = "milestone-freeze-update-interval"
+	milestoneOptFreezeDate           = "milestone-freeze-date"
+	milestoneOptAtRiskPercent        = "milestone-at-risk-percent"
+	milestoneOptEditNotifications    = "milestone-edit-notifications"
+	milestoneOptSuppressSummary      = "milestone-suppress-summary"
+	milestoneOptLabelsOnly           = "milestone-labels-only"
+	milestoneOptCleanupOrphaned      = "milestone-cleanup-orphaned"
+	milestoneOptCleanupClosed        = "milestone-cleanup-closed"
+	milestoneOptKeepWithOpenPR       = "milestone-keep-with-open-pr"
+	milestoneOptSigAllowlist         = "milestone-sig-allowlist"
+	milestoneOptStateWebhook         = "milestone-state-webhook"
+	milestoneOptBlockerLabel         = "milestone-blocker-label"
+	milestoneOptUseIssueTypes        = "milestone-use-issue-types"
+
+	milestoneOptLegacyBotNames             = "milestone-legacy-bot-names"
+	milestoneOptRepoMilestones             = "milestone-repo-milestones"
+	milestoneOptMessageTemplateFile        = "milestone-message-template-file"
+	milestoneOptDetailTemplateFile         = "milestone-detail-template-file"
+	milestoneOptRequireBlockerApproval     = "milestone-require-blocker-approval"
+	milestoneOptBlockerApprovalGracePeriod = "milestone-blocker-approval-grace-period"
+	milestoneOptSnoozeLabelPrefix          = "milestone-snooze-label-prefix"
+	milestoneOptBusinessDayGrace           = "milestone-business-day-grace"
+	milestoneOptHolidays                   = "milestone-holidays"
+	milestoneOptAttentionColumn            = "milestone-attention-column"
+	milestoneOptIgnoredUpdateActors        = "milestone-ignored-update-actors"
+	milestoneOptHealthThreshold            = "milestone-health-threshold"
+	milestoneOptRemovalCC                  = "milestone-removal-cc"
+	milestoneOptRequireETA                 = "milestone-require-eta"
+	milestoneOptETAPattern                 = "milestone-eta-pattern"
+	milestoneOptSingleSigOwner             = "milestone-single-sig-owner"
+	milestoneOptExemptAuthors              = "milestone-exempt-authors"
+	milestoneOptStaleThreshold             = "milestone-stale-threshold"
+	milestoneOptNoSigMentions              = "milestone-no-sig-mentions"
+	milestoneOptRestoreWindow              = "milestone-restore-window"
+	milestoneOptGraceFromFirstWarning      = "milestone-grace-from-first-warning"
+	milestoneOptRemovalExemptPriorities    = "milestone-removal-exempt-priorities"
+	milestoneOptRemovalExemptMilestones    = "milestone-removal-exempt-milestones"
+	milestoneOptTrackingIssue              = "milestone-tracking-issue"
+	milestoneOptStateSeverity              = "milestone-state-severity"
+	milestoneOptQuietHoursStart            = "milestone-quiet-hours-start"
+	milestoneOptQuietHoursEnd              = "milestone-quiet-hours-end"
+	milestoneOptQuietHoursTimezone         = "milestone-quiet-hours-timezone"
+	milestoneOptInProgressModes            = "milestone-in-progress-modes"
+	milestoneOptRemovalMode                = "milestone-removal-mode"
+	milestoneOptCloseOnFinalRemoval        = "milestone-close-on-final-removal"
+	milestoneOptStrictConfigValidation     = "milestone-strict-config-validation"
+	milestoneOptSummarizeLinkedPRs         = "milestone-summarize-linked-prs"
+	milestoneOptAreaSigMapping             = "milestone-area-sig-mapping"
+	milestoneOptSigEmails                  = "milestone-sig-emails"
+	milestoneOptIgnoreTitlePrefixes        = "milestone-ignore-title-prefixes"
+	milestoneOptRequireBlockerAssignee     = "milestone-require-blocker-assignee"
+	milestoneOptNotificationJitter         = "milestone-notification-jitter"
+	milestoneOptInProgressLabels           = "milestone-in-progress-labels"
+	milestoneOptRequireDesignDoc           = "milestone-require-design-doc"
+	milestoneOptDesignDocPattern           = "milestone-design-doc-pattern"
+	milestoneOptUseGraphQL                 = "milestone-use-graphql"
+	milestoneOptReleaseBranch              = "milestone-release-branch"
+	milestoneOptDefaultPriority            = "milestone-default-priority"
+	milestoneOptApprovalFromReview         = "milestone-approval-from-review"
+	milestoneOptSMTPServer                 = "milestone-smtp-server"
+	milestoneOptSMTPFrom                   = "milestone-smtp-from"
+
+	milestoneOptMutationRetries      = "milestone-mutation-retries"
+	milestoneOptMutationRetryBackoff = "milestone-mutation-retry-backoff"
+	milestoneOptRemovalBlackoutStart = "milestone-removal-blackout-start"
+	milestoneOptRemovalBlackoutEnd   = "milestone-removal-blackout-end"
+	milestoneOptBlackoutPausesGrace  = "milestone-blackout-pauses-grace"
+	milestoneOptTitlePattern         = "milestone-title-pattern"
+
+	milestoneOptMentionSigsWhenUnassigned = "milestone-mention-sigs-when-unassigned"
+
+	milestoneOptSigSlushUpdateIntervals  = "milestone-sig-slush-update-intervals"
+	milestoneOptSigFreezeUpdateIntervals = "milestone-sig-freeze-update-intervals"
+	milestoneOptSigMentionTemplate       = "milestone-sig-mention-template"
+	milestoneOptSigMentionOverrides      = "milestone-sig-mention-overrides"
+	milestoneOptHelpBaseURL              = "milestone-help-base-url"
+	milestoneOptSigHelpURLs              = "milestone-sig-help-urls"
+
+	milestoneDetailTemplate = `<details>
+<summary>Help</summary>
+<ul>
+ <li><a href="%s/contributors/devel/release/issues.md">Additional instructions</a></li>
+ <li><a href="%s/contributors/guide/bot-commands.md">Commands for setting labels</a></li>
+</ul>
+</details>
+`
+
+	milestoneMessageTemplate = `
+{{- if .warnUnapproved}}
+**Action required**: This {{.objType}} must have the {{.approvedLabel}} label applied by a SIG maintainer.{{.unapprovedSigReminder}}{{.unapprovedRemovalWarning}}{{.warningNumber}}
+{{end -}}
+{{- if .removeUnapproved}}
+**Important**: This {{.objType}} was missing the {{.approvedLabel}} label for more than {{.approvalGracePeriod}}.
+{{end -}}
+{{- if .warnMissingInProgress}}
+**Action required**: During code {{.mode}}, {{.objTypePlural}} in the milestone should be in progress.
+If this {{.objType}} is not being actively worked on, please remove it from the milestone.
+If it is being worked on, please add the {{.inProgressLabel}} label so it can be tracked with other in-flight {{.objTypePlural}}.
+{{end -}}
+{{- if .warnMissingAssignee}}
+**Action required**: This {{.objType}} is marked as a {{.blockerLabel}} but has no assignee. Please assign an owner who will be responsible for providing updates.
+{{end -}}
+{{- if .warnMissingDesignDoc}}
+**Action required**: No design reference was found in the description or comments of this feature {{.objType}}. Please link the design doc or proposal so the remaining risk can be assessed.
+{{end -}}
+{{- if .warnUpdateRequired}}
+**Action required**: This {{.objType}} has not been updated since {{.lastUpdated}}. Please provide an update.{{.warningNumber}}
+{{end -}}
+{{- if .warnMissingETA}}
+**Action required**: The latest update on this {{.objType}} does not include an ETA. During code {{.mode}}, updates on {{.blockerLabel}} {{.objTypePlural}} must include one (e.g. ` + "`ETA: DD/MM/YYYY`" + `). Please post an update with an ETA.
+{{end -}}
+{{- if .warnUpdateAtRisk}}
+**Note**: This {{.objType}} has not been updated since {{.lastUpdated}} and is at risk of missing its {{.updateInterval}} update interval. Please provide an update soon.
+{{end -}}
+{{- if .warnUpdateInterval}}
+**Note**: This {{.objType}} is marked as a {{.blockerLabel}}, and must be updated every {{.updateInterval}} during code {{.mode}}.
+
+Example update:
+
+` + "```" + `
+ACK.  In progress
+ETA: DD/MM/YYYY
+Risks: Complicated fix required
+` + "```" + `
+{{end -}}
+{{- if .warnNonBlockerRemoval}}
+**Note**: If this {{.objType}} is not resolved or labeled as {{.blockerLabel}} by {{.freezeDate}} it will be moved out of the {{.milestone}}.
+{{end -}}
+{{- if .removeNonBlocker}}
+**Important**: Code {{.mode}} is in effect and only {{.blockerLabel}} {{.objTypePlural}} will remain in the {{.milestone}}.
+{{end -}}
+{{- if .warnNeedsTriage}}
+**Action required**: This {{.objType}} has none of the labels required for the {{.milestone}} and needs triage. Please bring it to the attention of a SIG so that it can be triaged and labeled.
+
+{{range $index, $labelError := .labelErrors -}}
+{{$labelError}}
+{{end -}}
+{{end -}}
+{{- if .warnIncompleteLabels}}
+**Action required**: This {{.objType}} requires label changes.{{.incompleteLabelsRemovalWarning}}{{.warningNumber}}
+
+{{range $index, $labelError := .labelErrors -}}
+{{$labelError}}
+{{end -}}
+{{end -}}
+{{- if .removeByRequest}}
+**Important**: This {{.objType}} was removed from the {{.milestone}} at the request of {{.removalRequester}}.
+{{end -}}
+{{- if .removeIncompleteLabels}}
+**Important**: This {{.objType}} was missing labels required for the {{.milestone}} for more than {{.labelGracePeriod}}:
+
+{{range $index, $labelError := .labelErrors -}}
+{{$labelError}}
+{{end}}
+{{end -}}
+{{- if .summarizeLabels -}}
+<details{{if .warnIncompleteLabels}} open{{end}}>
+<summary>Issue Labels</summary>
+
+- {{range $index, $sigLabel := .sigLabels}}{{if $index}} {{end}}{{$sigLabel}}{{end}}: Issue will be escalated to these SIGs if needed.
+- {{.priorityLabel}}: {{.priorityDescription}}
+- {{.kindLabel}}: {{.kindDescription}}
+{{- if .linkedPRs}}
+- Linked PRs: {{range $index, $pr := .linkedPRs}}{{if $index}}, {{end}}#{{$pr}}{{end}}
+{{- end}}
+
+</details>
+{{- end -}}
+`
+)
+
+// milestoneFirstContactMessageTemplate is the variant of the message
+// template used for the very first notification on an issue. The
+// reader is getting a heads-up rather than a repeat reminder, so the
+// demanding headers are softened.
+var milestoneFirstContactMessageTemplate = strings.NewReplacer(
+	"**Action required**:", "**Heads-up**:",
+).Replace(milestoneMessageTemplate)
+
+const (
+	milestoneCurrent milestoneState = iota
+	milestoneAtRisk
+	milestoneNeedsTriage
+	milestoneNeedsLabeling
+	milestoneNeedsApproval
+	milestoneNeedsAttention
+	milestoneNeedsRemoval
+)
+
+// String returns the name of the state used in the status payload.
+func (s milestoneState) String() string {
+	switch s {
+	case milestoneCurrent:
+		return "current"
+	case milestoneAtRisk:
+		return "at-risk"
+	case milestoneNeedsTriage:
+		return "needs-triage"
+	case milestoneNeedsLabeling:
+		return "needs-labeling"
+	case milestoneNeedsApproval:
+		return "needs-approval"
+	case milestoneNeedsAttention:
+		return "needs-attention"
+	case milestoneNeedsRemoval:
+		return "needs-removal"
+	}
+	return "unknown"
+}
+
+var (
+	milestoneModes = sets.NewString(milestoneModeDev, milestoneModeSlush, milestoneModeFreeze)
+
+	milestoneRemovalModes = sets.NewString(milestoneRemovalModeClear, milestoneRemovalModeLabelOnly)
+
+	milestoneStateConfigs = map[milestoneState]milestoneStateConfig{
+		milestoneCurrent: {
+			title: "Milestone Issue **Current**",
+		},
+		milestoneAtRisk: {
+			title: "Milestone Issue **At Risk**",
+			label: milestoneAtRiskLabel,
+		},
+		milestoneNeedsTriage: {
+			title:          "Milestone Issue **Needs Triage**",
+			label:          milestoneNeedsTriageLabel,
+			warnOnInterval: true,
+		},
+		milestoneNeedsLabeling: {
+			title:          "Milestone Labels **Incomplete**",
+			label:          milestoneLabelsIncompleteLabel,
+			warnOnInterval: true,
+			notifySIGs:     true,
+		},
+		milestoneNeedsApproval: {
+			title:          "Milestone Issue **Needs Approval**",
+			label:          milestoneNeedsApprovalLabel,
+			warnOnInterval: true,
+			notifySIGs:     true,
+		},
+		milestoneNeedsAttention: {
+			title:          "Milestone Issue **Needs Attention**",
+			label:          milestoneNeedsAttentionLabel,
+			warnOnInterval: true,
+			notifySIGs:     true,
+		},
+		milestoneNeedsRemoval: {
+			title:      "Milestone **Removed**",
+			label:      milestoneRemovedLabel,
+			notifySIGs: true,
+		},
+	}
+
+	// milestoneStateLabels is the set of milestone state labels
+	// applied by the maintainer. statusApprovedLabel is not included
+	// because it is applied manually rather than by the maintainer.
+	milestoneStateLabels = []string{
+		milestoneAtRiskLabel,
+		milestoneLabelsIncompleteLabel,
+		milestoneNeedsTriageLabel,
+		milestoneNeedsApprovalLabel,
+		milestoneNeedsAttentionLabel,
+		milestoneRemovedLabel,
+	}
+
+	kindMap = map[string]string{
+		"kind/bug":     "Fixes a bug discovered during the current release.",
+		"kind/feature": "New functionality.",
+		"kind/cleanup": "Adding tests, refactoring, fixing old bugs.",
+	}
+
+	// issueTypeKindMap maps native github issue type names to the kind
+	// labels they are equivalent to.
+	issueTypeKindMap = map[string]string{
+		"bug":     "kind/bug",
+		"feature": "kind/feature",
+		"task":    "kind/cleanup",
+	}
+
+	priorityMap = map[string]string{
+		blockerLabel:                  "Never automatically move %s out of a release milestone; continually escalate to contributor and SIG through all available channels.",
+		"priority/important-soon":     "Escalate to the %s owners and SIG owner; move out of milestone after several unsuccessful escalation attempts.",
+		"priority/important-longterm": "Escalate to the %s owners; move out of the milestone after 1 attempt.",
+	}
+)
+
+// clock abstracts the current time so that time-dependent behavior
+// can be tested deterministically.
+type clock interface {
+	Now() time.Time
+}
+
+// realClock is the default clock, backed by time.Now.
+type realClock struct{}
+
+// Now returns the current time.
+func (realClock) Now() time.Time { return time.Now() }
+
+// milestoneAuditRecord captures a single mutation made by the
+// maintainer for the compliance trail.
+type milestoneAuditRecord struct {
+	Issue     int      `json:"issue"`
+	Action    string   `json:"action"`
+	OldLabels []string `json:"old-labels,omitempty"`
+	NewLabel  string   `json:"new-label,omitempty"`
+	State     string   `json:"state"`
+	Mode      string   `json:"mode"`
+}
+
+// milestoneAuditSink records the mutations made by the maintainer.
+// Failures to record must not block the mutation itself.
+type milestoneAuditSink interface {
+	Record(record milestoneAuditRecord) error
+}
+
+// glogAuditSink writes audit records to the standard log.
+type glogAuditSink struct{}
+
+// Record logs the given audit record.
+func (glogAuditSink) Record(record milestoneAuditRecord) error {
+	glog.Infof("milestone audit: issue=%d action=%s old-labels=%v new-label=%q state=%s mode=%s",
+		record.Issue, record.Action, record.OldLabels, record.NewLabel, record.State, record.Mode)
+	return nil
+}
+
+// issueChange encapsulates changes to make to an issue.
+type issueChange struct {
+	notification *c.Notification
+	label        string
+	// The state of the issue after the change is applied
+	state milestoneState
+	// The interval after which a matching notification should be reposted
+	commentInterval *time.Duration
+}
+
+// MilestoneMaintainer enforces the process for shepherding issues into
+// the release:
+//
+//   - required labels (kind, priority and sig) must be set
+//   - non-blocking issues require sig approval to remain in the milestone
+//   - during code slush and freeze, blocking issues must be in progress
+//     and updated regularly
+//   - during code freeze, non-blocking issues are removed from the
+//     milestone
+type MilestoneMaintainer struct {
+	botName  string
+	config   *github.Config
+	features *features.Features
+
+	// optionsLock excludes option reloads while a munge is reading the
+	// option-backed fields, so a mid-loop config change cannot produce
+	// torn reads (e.g. the mode changed but not its update interval).
+	optionsLock sync.RWMutex
+
+	statusLock            sync.Mutex
+	currentStatus         *milestoneStatus
+	nextStatus            *milestoneStatus
+	lastLoopTime          time.Time
+	lastLoopAPICalls      map[string]int
+	totalAPICalls         map[string]int
+	templateErrorCount    int
+	registerStatusHandler sync.Once
+
+	activeMilestone     string
+	mode                string
+	autoReaddBlockers   bool
+	extraLabelGroupSpec string
+	extraLabelGroups    []requiredLabelGroup
+	warningInterval     time.Duration
+	notificationJitter  time.Duration
+	warningBackoff      bool
+	warningBackoffCap   time.Duration
+	labelGracePeriod    time.Duration
+	gracePeriodFloor    time.Duration
+	approvalGracePeriod time.Duration
+	slushUpdateInterval time.Duration
+
+	requireBlockerApproval     bool
+	blockerApprovalGracePeriod time.Duration
+	requireBlockerAssignee     bool
+
+	legacyBotNames      []string
+	ignoredUpdateActors []string
+	removalCC           []string
+	exemptAuthors       []string
+
+	repoMilestonesSpec string
+	repoMilestones     map[string]string
+
+	messageTemplateFile     string
+	detailTemplateFile      string
+	messageTemplateOverride string
+	detailTemplateOverride  string
+
+	freezeUpdateInterval    time.Duration
+	atRiskPercent           int
+	editNotifications       bool
+	suppressSummary         bool
+	labelsOnly              bool
+	cleanupClosed           bool
+	cleanupOrphaned         bool
+	keepWithOpenPR          bool
+	freezeDate              string
+	snoozeLabelPrefix       string
+	businessDayGrace        bool
+	holidaysSpec            string
+	holidays                map[string]bool
+	attentionColumnID       int
+	healthThreshold         time.Duration
+	requireETA              bool
+	etaPatternSpec          string
+	etaPattern              *regexp.Regexp
+	requireDesignDoc        bool
+	useGraphQL              bool
+	releaseBranch           string
+	defaultPriority         string
+	approvalFromReview      bool
+	smtpServer              string
+	smtpFrom                string
+	designDocPatternSpec    string
+	designDocPattern        *regexp.Regexp
+	singleSigOwner          bool
+	staleThreshold          time.Duration
+	noSigMentions           bool
+	restoreWindow           time.Duration
+	graceFromFirstWarning   bool
+	removalExemptPriorities []string
+	removalExemptMilestones []string
+	trackingIssueNumber     int
+	stateSeveritySpec       string
+	stateSeverity           []milestoneState
+	quietHoursStartSpec     string
+	quietHoursEndSpec       string
+	quietHoursTimezone      string
+	quietHoursStart         int
+	quietHoursEnd           int
+	quietHoursLocation      *time.Location
+	inProgressModesSpec     string
+	inProgressModes         sets.String
+	inProgressLabels        []string
+	removalMode             string
+	closeOnFinalRemoval     bool
+	strictConfigValidation  bool
+	summarizeLinkedPRs      bool
+	areaSigMapSpec          string
+	areaSigMap              map[string]string
+
+	mutationRetries      int
+	mutationRetryBackoff time.Duration
+
+	removalBlackoutStartSpec string
+	removalBlackoutEndSpec   string
+	removalBlackoutStart     time.Time
+	removalBlackoutEnd       time.Time
+	blackoutPausesGrace      bool
+
+	sigSlushIntervalSpec  string
+	sigFreezeIntervalSpec string
+	sigSlushIntervals     map[string]time.Duration
+	sigFreezeIntervals    map[string]time.Duration
+
+	titlePatternSpec string
+	titlePattern     *regexp.Regexp
+
+	sigAllowlistSpec string
+	sigAllowlist     sets.String
+
+	stateWebhookURL string
+	blockerLabel    string
+	useIssueTypes   bool
+
+	auditSink                 milestoneAuditSink
+	logger                    *milestoneLogger
+	mailer                    milestoneMailer
+	sigEmailsSpec             string
+	sigEmails                 map[string]string
+	ignoreTitlePrefixes       []string
+	sigMentionTemplate        string
+	sigMentionOverridesSpec   string
+	sigMentionOverrides       map[string]string
+	helpBaseURL               string
+	sigHelpURLsSpec           string
+	sigHelpURLs               map[string]string
+	mentionSigsWhenUnassigned bool
+
+	clock clock
+	sleep func(time.Duration)
+}
+
+// now returns the current time from the configured clock, defaulting
+// to the real clock.
+func (m *MilestoneMaintainer) now() time.Time {
+	if m.clock == nil {
+		return time.Now()
+	}
+	return m.clock.Now()
+}
+
+// pause blocks for the given duration via the configured sleep
+// function, defaulting to time.Sleep.
+func (m *MilestoneMaintainer) pause(duration time.Duration) {
+	if m.sleep == nil {
+		time.Sleep(duration)
+		return
+	}
+	m.sleep(duration)
+}
+
+// retryMutation invokes the given mutation, retrying transient
+// failures with exponential backoff so that a single flaky response
+// does not leave an issue with labels half-applied. When github
+// signals a rate limit, the server-provided retry interval is honored
+// if it exceeds the computed backoff.
+func (m *MilestoneMaintainer) retryMutation(description string, mutate func() error) error {
+	backoff := m.mutationRetryBackoff
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = mutate()
+		if err == nil {
+			return nil
+		}
+		if attempt >= m.mutationRetries {
+			break
+		}
+		wait := backoff
+		if retryAfter, rateLimited := rateLimitRetryAfter(err, m.now()); rateLimited && retryAfter > wait {
+			wait = retryAfter
+		}
+		glog.Warningf("Error %s (attempt %d of %d), retrying in %v: %v", description, attempt+1, m.mutationRetries+1, wait, err)
+		m.pause(wait)
+		backoff *= 2
+	}
+	glog.Errorf("Error %s, giving up: %v", description, err)
+	return err
+}
+
+// rateLimitRetryAfter returns the interval github has asked us to wait
+// before retrying, if the given error indicates a rate limit.
+func rateLimitRetryAfter(err error, now time.Time) (time.Duration, bool) {
+	switch limitErr := err.(type) {
+	case *githubapi.RateLimitError:
+		return limitErr.Rate.Reset.Time.Sub(now), true
+	case *githubapi.AbuseRateLimitError:
+		if limitErr.RetryAfter != nil {
+			return *limitErr.RetryAfter, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func init() {
+	RegisterMungerOrDie(&MilestoneMaintainer{})
+}
+
+// Name is the name usable in --mungers
+func (m *MilestoneMaintainer) Name() string { return "milestone-maintainer" }
+
+// RequiredFeatures is a slice of 'features' that must be provided
+func (m *MilestoneMaintainer) RequiredFeatures() []string { return []string{} }
+
+// Initialize will initialize the munger
+func (m *MilestoneMaintainer) Initialize(config *github.Config, features *features.Features) error {
+	if len(m.activeMilestone) == 0 {
+		return fmt.Errorf("option %q is required", milestoneOptActiveMilestone)
+	}
+	if !milestoneModes.Has(m.mode) {
+		return fmt.Errorf("option %q must be one of %v", milestoneOptMode, milestoneModes.List())
+	}
+	if err := m.loadTemplateOverrides(); err != nil {
+		return err
+	}
+	m.botName = config.BotName
+	m.config = config
+	m.features = features
+	if m.logger == nil {
+		m.logger = newMilestoneLogger(nil)
+	}
+	m.registerStatusHandler.Do(func() {
+		http.Handle("/milestone-status", m)
+		http.HandleFunc("/milestone-reconcile", m.handleReconcile)
+		http.HandleFunc("/milestone-preview", m.handlePreview)
+		http.HandleFunc("/milestone-health", m.handleHealth)
+		http.HandleFunc("/milestone-metrics", m.handleMetrics)
+	})
+	return nil
+}
+
+// EachLoop is called at the start of every munge loop. The munger uses
+// it to publish the milestone status accumulated during the previous
+// loop and to verify that the active milestone still exists; munging
+// an issue only requires state local to the issue.
+func (m *MilestoneMaintainer) EachLoop() error {
+	m.optionsLock.RLock()
+	defer m.optionsLock.RUnlock()
+	m.publishStatus()
+	if m.config != nil {
+		exists, err := m.config.MilestoneExists(m.activeMilestone)
+		if err != nil {
+			return fmt.Errorf("unable to verify active milestone %q: %v", m.activeMilestone, err)
+		}
+		if !exists {
+			return fmt.Errorf("active milestone %q does not exist; it may have been deleted or closed", m.activeMilestone)
+		}
+	}
+	m.postTrackingSummary()
+	m.recordAPICallCounts()
+	m.recordHeartbeat()
+	return nil
+}
+
+// recordHeartbeat timestamps the successful completion of a munge
+// loop, for the health endpoint to report on.
+func (m *MilestoneMaintainer) recordHeartbeat() {
+	m.statusLock.Lock()
+	defer m.statusLock.Unlock()
+	m.lastLoopTime = m.now()
+}
+
+// RegisterOptions registers options for this munger; returns any that
+// require a restart when changed.
+func (m *MilestoneMaintainer) RegisterOptions(opts *options.Options) sets.String {
+	opts.RegisterLock(&m.optionsLock)
+	opts.RegisterString(&m.activeMilestone, milestoneOptActiveMilestone, "", "The active milestone (e.g. v1.10) whose issues the munger should maintain.")
+	opts.RegisterString(&m.mode, milestoneOptMode, milestoneModeDev, fmt.Sprintf("The release cycle mode to enforce. One of %v.", milestoneModes.List()))
+	opts.RegisterBool(&m.autoReaddBlockers, milestoneOptAutoReaddBlockers, false, "Whether issues previously removed from the active milestone should be re-added when they regain blocker status.")
+	opts.RegisterString(&m.extraLabelGroupSpec, milestoneOptRequiredLabelGroups, "", "Additional required label groups in the form 'name:exactly-one|at-least-one:label1|label2;...'. A single label ending in '/' matches by prefix.")
+	opts.RegisterUpdateCallback(func(changed sets.String) error {
+		if changed.Has(milestoneOptRequiredLabelGroups) {
+			groups, err := parseRequiredLabelGroups(m.extraLabelGroupSpec)
+			if err != nil {
+				return err
+			}
+			m.extraLabelGroups = groups
+		}
+		if changed.Has(milestoneOptSigSlushUpdateIntervals) {
+			intervals, err := parseSigUpdateIntervals(m.sigSlushIntervalSpec)
+			if err != nil {
+				return err
+			}
+			m.sigSlushIntervals = intervals
+		}
+		if changed.Has(milestoneOptSigFreezeUpdateIntervals) {
+			intervals, err := parseSigUpdateIntervals(m.sigFreezeIntervalSpec)
+			if err != nil {
+				return err
+			}
+			m.sigFreezeIntervals = intervals
+		}
+		if changed.Has(milestoneOptRemovalMode) && !milestoneRemovalModes.Has(m.removalMode) {
+			return fmt.Errorf("option %q must be one of %v", milestoneOptRemovalMode, milestoneRemovalModes.List())
+		}
+		if changed.HasAny(milestoneOptWarningInterval, milestoneOptLabelGracePeriod, milestoneOptApprovalGracePeriod, milestoneOptStrictConfigValidation) {
+			if err := m.validateGracePeriods(); err != nil {
+				return err
+			}
+		}
+		if changed.Has(milestoneOptSigEmails) {
+			emails, err := parseSigEmails(m.sigEmailsSpec)
+			if err != nil {
+				return err
+			}
+			m.sigEmails = emails
+		}
+		if changed.Has(milestoneOptSigMentionOverrides) {
+			overrides, err := parseSigMentionOverrides(m.sigMentionOverridesSpec)
+			if err != nil {
+				return err
+			}
+			m.sigMentionOverrides = overrides
+		}
+		if changed.Has(milestoneOptAreaSigMapping) {
+			mapping, err := parseAreaSigMapping(m.areaSigMapSpec)
+			if err != nil {
+				return err
+			}
+			m.areaSigMap = mapping
+		}
+		if changed.Has(milestoneOptInProgressModes) {
+			modes, err := parseModeList(m.inProgressModesSpec)
+			if err != nil {
+				return err
+			}
+			m.inProgressModes = modes
+		}
+		if changed.Has(milestoneOptQuietHoursStart) || changed.Has(milestoneOptQuietHoursEnd) || changed.Has(milestoneOptQuietHoursTimezone) {
+			if err := m.parseQuietHours(); err != nil {
+				return err
+			}
+		}
+		if changed.Has(milestoneOptStateSeverity) {
+			severity, err := parseStateSeverity(m.stateSeveritySpec)
+			if err != nil {
+				return err
+			}
+			m.stateSeverity = severity
+		}
+		if changed.Has(milestoneOptSigHelpURLs) {
+			helpURLs, err := parseSigHelpURLs(m.sigHelpURLsSpec)
+			if err != nil {
+				return err
+			}
+			m.sigHelpURLs = helpURLs
+		}
+		if changed.Has(milestoneOptTitlePattern) {
+			if m.titlePatternSpec == "" {
+				m.titlePattern = nil
+			} else {
+				pattern, err := regexp.Compile(m.titlePatternSpec)
+				if err != nil {
+					return fmt.Errorf("unable to compile %q: %v", milestoneOptTitlePattern, err)
+				}
+				m.titlePattern = pattern
+			}
+		}
+		if changed.Has(milestoneOptMessageTemplateFile) || changed.Has(milestoneOptDetailTemplateFile) {
+			if err := m.loadTemplateOverrides(); err != nil {
+				return err
+			}
+		}
+		if changed.Has(milestoneOptRepoMilestones) {
+			repoMilestones, err := parseRepoMilestones(m.repoMilestonesSpec)
+			if err != nil {
+				return err
+			}
+			m.repoMilestones = repoMilestones
+		}
+		if changed.Has(milestoneOptETAPattern) {
+			etaPattern, err := regexp.Compile(m.etaPatternSpec)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %v", milestoneOptETAPattern, err)
+			}
+			m.etaPattern = etaPattern
+		}
+		if changed.Has(milestoneOptDesignDocPattern) {
+			designDocPattern, err := regexp.Compile(m.designDocPatternSpec)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %v", milestoneOptDesignDocPattern, err)
+			}
+			m.designDocPattern = designDocPattern
+		}
+		if changed.HasAny(milestoneOptSMTPServer, milestoneOptSMTPFrom) {
+			if m.smtpServer == "" {
+				m.mailer = nil
+			} else {
+				if m.smtpFrom == "" {
+					return fmt.Errorf("%s requires %s", milestoneOptSMTPServer, milestoneOptSMTPFrom)
+				}
+				m.mailer = &smtpMailer{server: m.smtpServer, from: m.smtpFrom}
+			}
+		}
+		if changed.Has(milestoneOptDefaultPriority) && m.defaultPriority != "" {
+			if _, ok := priorityMap[m.defaultPriority]; !ok {
+				return fmt.Errorf("option %q must be one of %v", milestoneOptDefaultPriority, sortedLabelNames(priorityMap))
+			}
+		}
+		if changed.Has(milestoneOptRemovalCC) {
+			for _, handle := range m.removalCC {
+				if strings.TrimSpace(handle) == "" {
+					return fmt.Errorf("%s must not contain empty handles", milestoneOptRemovalCC)
+				}
+			}
+		}
+		if changed.Has(milestoneOptHolidays) {
+			holidays, err := parseHolidays(m.holidaysSpec)
+			if err != nil {
+				return err
+			}
+			m.holidays = holidays
+		}
+		if changed.Has(milestoneOptBlockerLabel) {
+			if !strings.HasPrefix(m.blockerLabel, priorityLabelPrefix) {
+				return fmt.Errorf("%s must be a priority label, got %q", milestoneOptBlockerLabel, m.blockerLabel)
+			}
+			if _, ok := priorityMap[m.blockerLabel]; !ok {
+				// Make the configured label a valid priority choice,
+				// carrying the blocker description.
+				priorityMap[m.blockerLabel] = priorityMap[blockerLabel]
+			}
+		}
+		if changed.Has(milestoneOptSigAllowlist) {
+			allowlist, err := parseSigAllowlist(m.sigAllowlistSpec)
+			if err != nil {
+				return err
+			}
+			m.sigAllowlist = allowlist
+		}
+		if changed.Has(milestoneOptRemovalBlackoutStart) || changed.Has(milestoneOptRemovalBlackoutEnd) {
+			start, end, err := parseRemovalBlackout(m.removalBlackoutStartSpec, m.removalBlackoutEndSpec)
+			if err != nil {
+				return err
+			}
+			m.removalBlackoutStart = start
+			m.removalBlackoutEnd = end
+		}
+		return nil
+	})
+	opts.RegisterDuration(&m.warningInterval, milestoneOptWarningInterval, 24*time.Hour, "The interval at which warning notifications are repeated.")
+	opts.RegisterDuration(&m.notificationJitter, milestoneOptNotificationJitter, 0, "The window over which due notifications are spread to avoid paging every sig at once, e.g. after a config change makes many issues stale in the same loop. Each issue posts in a deterministic slot within the window. 0 posts immediately.")
+	opts.RegisterBool(&m.warningBackoff, milestoneOptWarningBackoff, false, "Whether the interval at which a warning repeats should double each time it is reposted.")
+	opts.RegisterDuration(&m.warningBackoffCap, milestoneOptWarningBackoffCap, 7*day, "The maximum effective warning interval when backoff is enabled.")
+	opts.RegisterDuration(&m.healthThreshold, milestoneOptHealthThreshold, time.Hour, "The maximum age of the last completed munge loop before the health endpoint reports unhealthy. 0 always reports healthy.")
+	opts.RegisterDuration(&m.staleThreshold, milestoneOptStaleThreshold, 0, "The duration without activity after which an issue is labeled stale. 0 disables stale labeling.")
+	opts.RegisterBool(&m.noSigMentions, milestoneOptNoSigMentions, false, "Whether to suppress sig team mentions in notifications. Assignees and authors are still mentioned.")
+	opts.RegisterDuration(&m.restoreWindow, milestoneOptRestoreWindow, 0, "The window after an automated removal during which the restore command can return the issue to the milestone. 0 disables restores.")
+	opts.RegisterBool(&m.graceFromFirstWarning, milestoneOptGraceFromFirstWarning, false, "Whether to anchor grace periods to the first warning notification instead of the state label, making the countdown robust to label flapping.")
+	opts.RegisterDuration(&m.labelGracePeriod, milestoneOptLabelGracePeriod, 3*day, "The grace period to allow an issue with incomplete labels to remain in the milestone.")
+	opts.RegisterDuration(&m.approvalGracePeriod, milestoneOptApprovalGracePeriod, 7*day, "The grace period to allow an unapproved issue to remain in the milestone.")
+	opts.RegisterBool(&m.strictConfigValidation, milestoneOptStrictConfigValidation, false, "Whether an inconsistent grace period configuration is an error rather than a logged warning.")
+	opts.RegisterDuration(&m.gracePeriodFloor, milestoneOptGracePeriodFloor, day, "The minimum effective grace period, guarding against a misconfigured tiny grace period removing an issue immediately after it was labeled.")
+	opts.RegisterDuration(&m.slushUpdateInterval, milestoneOptSlushUpdateInterval, 3*day, "The interval at which blocking issues must be updated during code slush.")
+	opts.RegisterDuration(&m.freezeUpdateInterval, milestoneOptFreezeUpdateInterval, day, "The interval at which blocking issues must be updated during code freeze.")
+	opts.RegisterString(&m.sigSlushIntervalSpec, milestoneOptSigSlushUpdateIntervals, "", "Per-sig overrides of the slush update interval in the form 'sig/node=24h,sig/docs=72h'.")
+	opts.RegisterString(&m.sigFreezeIntervalSpec, milestoneOptSigFreezeUpdateIntervals, "", "Per-sig overrides of the freeze update interval in the form 'sig/node=24h,sig/docs=72h'.")
+	opts.RegisterInt(&m.atRiskPercent, milestoneOptAtRiskPercent, 75, "The percentage of the update interval after which a blocking issue without an update is considered at risk. Values outside (0, 100) disable the at-risk state.")
+	opts.RegisterInt(&m.mutationRetries, milestoneOptMutationRetries, 2, "The number of times a failed github mutation will be retried before the munge for the issue is aborted.")
+	opts.RegisterDuration(&m.mutationRetryBackoff, milestoneOptMutationRetryBackoff, 5*time.Second, "The initial interval to wait before retrying a failed github mutation. The interval doubles with each retry.")
+	opts.RegisterBool(&m.editNotifications, milestoneOptEditNotifications, false, "Whether a changed notification should be edited in place rather than deleted and reposted, preserving replies and reactions on the existing comment.")
+	opts.RegisterBool(&m.suppressSummary, milestoneOptSuppressSummary, false, "Whether the label summary notification should be skipped for issues that are current, keeping the munger silent on healthy issues.")
+	opts.RegisterBool(&m.labelsOnly, milestoneOptLabelsOnly, false, "Whether the munger should only maintain state labels and the milestone itself, posting no notification comments.")
+	opts.RegisterBool(&m.cleanupClosed, milestoneOptCleanupClosed, false, "Whether stale state labels should be removed from closed issues in the active milestone. No comments are posted on closed issues.")
+	opts.RegisterBool(&m.cleanupOrphaned, milestoneOptCleanupOrphaned, false, "Whether milestone state labels should be stripped from issues that a human has moved to a different milestone, which the munger otherwise ignores.")
+	opts.RegisterBool(&m.keepWithOpenPR, milestoneOptKeepWithOpenPR, false, "Whether a non-blocking issue with an open linked pull request should be kept in the milestone during code freeze instead of being removed.")
+	opts.RegisterString(&m.releaseBranch, milestoneOptReleaseBranch, "", "The base branch (e.g. release-1.10) of pending fix pull requests. An issue with an open linked pull request targeting this branch is kept in the milestone instead of being removed. Empty disables the check.")
+	opts.RegisterString(&m.defaultPriority, milestoneOptDefaultPriority, "", "The priority label (e.g. priority/important-longterm) to apply to an issue lacking one instead of warning about incomplete labels. Empty disables the default.")
+	opts.RegisterBool(&m.approvalFromReview, milestoneOptApprovalFromReview, false, fmt.Sprintf("Whether an approving review from a sig member on a linked open pull request satisfies the %s requirement.", statusApprovedLabel))
+	opts.RegisterString(&m.smtpServer, milestoneOptSMTPServer, "", "The host:port of an SMTP relay for mirroring notifications to sig mailing lists. Empty disables email delivery.")
+	opts.RegisterString(&m.smtpFrom, milestoneOptSMTPFrom, "", "The From address for notification email sent through the SMTP relay.")
+	opts.RegisterBool(&m.summarizeLinkedPRs, milestoneOptSummarizeLinkedPRs, false, "Whether the label summary should list the issue's open linked pull requests. Off by default since the timeline lookup costs an extra API call per issue.")
+	opts.RegisterString(&m.areaSigMapSpec, milestoneOptAreaSigMapping, "", "A mapping from area labels to owning sig labels in the form 'area/kubelet=sig/node,area/docs=sig/docs'. An issue without direct sig labels derives its sig set from its mapped area labels.")
+	opts.RegisterString(&m.sigEmailsSpec, milestoneOptSigEmails, "", "A mapping from sig labels to email addresses in the form 'sig/node=node-leads@example.com,...'. Removal and approval notifications for a mapped sig are additionally sent by email.")
+	opts.RegisterString(&m.removalBlackoutStartSpec, milestoneOptRemovalBlackoutStart, "", fmt.Sprintf("The first day (%s, inclusive) of a window during which issues are not removed from the milestone.", milestoneBlackoutDateFormat))
+	opts.RegisterString(&m.removalBlackoutEndSpec, milestoneOptRemovalBlackoutEnd, "", fmt.Sprintf("The last day (%s, inclusive) of a window during which issues are not removed from the milestone.", milestoneBlackoutDateFormat))
+	opts.RegisterBool(&m.blackoutPausesGrace, milestoneOptBlackoutPausesGrace, false, "Whether grace periods should stop counting down during the removal blackout window.")
+	opts.RegisterString(&m.freezeDate, milestoneOptFreezeDate, "", "The date on which code freeze takes effect, for inclusion in notifications (e.g. \"Nov 22\"). An RFC3339 timestamp additionally promotes slush to freeze once the date has passed.")
+	opts.RegisterString(&m.titlePatternSpec, milestoneOptTitlePattern, "", "A regex matching plausible milestone titles. Issues whose milestone matches the pattern without being the active milestone are logged as likely typos.")
+	opts.RegisterString(&m.sigAllowlistSpec, milestoneOptSigAllowlist, "", "A comma-separated list of sig labels (e.g. 'sig/node,sig/storage') to restrict the munger to, supporting a gradual rollout. Empty processes all milestone issues.")
+	opts.RegisterString(&m.stateWebhookURL, milestoneOptStateWebhook, "", "A URL that is POSTed a JSON payload whenever an issue's milestone state changes, for push updates to external dashboards.")
+	opts.RegisterString(&m.blockerLabel, milestoneOptBlockerLabel, blockerLabel, "The priority label that marks an issue as a release blocker.")
+	opts.RegisterString(&m.snoozeLabelPrefix, milestoneOptSnoozeLabelPrefix, defaultSnoozeLabelPrefix, fmt.Sprintf("The prefix of labels (e.g. %s2018-06-01) that suspend munging of an issue until the embedded %s date has passed.", defaultSnoozeLabelPrefix, milestoneBlackoutDateFormat))
+	opts.RegisterBool(&m.businessDayGrace, milestoneOptBusinessDayGrace, false, "Whether grace periods should be computed in business time, skipping weekends and configured holidays.")
+	opts.RegisterString(&m.holidaysSpec, milestoneOptHolidays, "", fmt.Sprintf("A comma-separated list of %s dates that do not count as business time when computing grace periods.", milestoneBlackoutDateFormat))
+	opts.RegisterInt(&m.attentionColumnID, milestoneOptAttentionColumn, 0, "The ID of a project board column that should track issues needing attention. Issues are added to the column on entering the needs-attention state and removed once resolved. 0 disables board sync.")
+	opts.RegisterString(&m.messageTemplateFile, milestoneOptMessageTemplateFile, "", "The path of a file overriding the built-in notification message template. The override is test-rendered at load time; an empty path uses the built-in template.")
+	opts.RegisterString(&m.detailTemplateFile, milestoneOptDetailTemplateFile, "", "The path of a file overriding the built-in help detail block appended to notifications. An empty path uses the built-in block.")
+	opts.RegisterString(&m.repoMilestonesSpec, milestoneOptRepoMilestones, "", "A comma-separated list of org/repo=milestone pairs overriding the active milestone per repository, for running one munger across several repos sharing a release cadence. Repos without an entry use the active milestone.")
+	opts.RegisterStringSlice(&m.legacyBotNames, milestoneOptLegacyBotNames, []string{}, "A comma-separated list of legacy bot logins whose comments and label events are recognized alongside the current bot, so that grace periods survive a bot account migration.")
+	opts.RegisterStringSlice(&m.ignoredUpdateActors, milestoneOptIgnoredUpdateActors, []string{}, "A comma-separated list of logins (e.g. CI reporters) whose comments do not count as updates when computing an issue's last-modification time, in addition to the bot itself.")
+	opts.RegisterStringSlice(&m.ignoreTitlePrefixes, milestoneOptIgnoreTitlePrefixes, []string{}, "A comma-separated list of title prefixes (e.g. '[PLACEHOLDER]') marking intentionally incomplete tracker issues the munger should skip entirely.")
+	opts.RegisterStringSlice(&m.removalCC, milestoneOptRemovalCC, []string{}, "A comma-separated list of handles (e.g. the release team) CC'd on every removal notification, beyond the usual mentions.")
+	opts.RegisterBool(&m.requireETA, milestoneOptRequireETA, false, "Whether blocker updates during code freeze must include an ETA line. A recent update without one keeps the update warning active.")
+	opts.RegisterString(&m.etaPatternSpec, milestoneOptETAPattern, defaultETAPattern, "The regex that an update comment must match to count as including an ETA.")
+	opts.RegisterBool(&m.requireDesignDoc, milestoneOptRequireDesignDoc, false, fmt.Sprintf("Whether %s issues without a design reference in their description or comments are warned about outside the dev mode.", featureKindLabel))
+	opts.RegisterString(&m.designDocPatternSpec, milestoneOptDesignDocPattern, defaultDesignDocPattern, "The regex that the issue description or a comment must match to count as linking a design doc.")
+	opts.RegisterBool(&m.useGraphQL, milestoneOptUseGraphQL, false, "Whether an issue's labels, milestone, comments and events are prefetched in a single GraphQL request instead of several REST calls. Requires a GraphQL-capable endpoint; a failed prefetch falls back to REST.")
+	opts.RegisterBool(&m.singleSigOwner, milestoneOptSingleSigOwner, false, "Whether an issue must carry exactly one owning sig label rather than at least one.")
+	opts.RegisterStringSlice(&m.exemptAuthors, milestoneOptExemptAuthors, []string{}, "A comma-separated list of logins (e.g. dependency bots) whose issues are ignored by the munger entirely.")
+	opts.RegisterStringSlice(&m.removalExemptPriorities, milestoneOptRemovalExemptPriorities, []string{}, "A comma-separated list of priority labels that are treated like blockers for removal purposes: their issues are warned but never moved out of the milestone.")
+	opts.RegisterStringSlice(&m.removalExemptMilestones, milestoneOptRemovalExemptMilestones, []string{}, "A comma-separated list of milestone titles (e.g. patch milestones) whose issues are warned but never moved out of the milestone.")
+	opts.RegisterInt(&m.trackingIssueNumber, milestoneOptTrackingIssue, 0, "The number of a pinned tracking issue to post a per-loop rollup comment on. 0 disables the rollup.")
+	opts.RegisterString(&m.stateSeveritySpec, milestoneOptStateSeverity, "", "A comma-separated ordering of state names, most severe first, used to select the reported state when an issue qualifies for several. Empty uses the built-in ordering.")
+	opts.RegisterString(&m.quietHoursStartSpec, milestoneOptQuietHoursStart, "", "The wall-clock start of the quiet-hours window (e.g. 22:00) during which notification comments are withheld. Labels are still maintained.")
+	opts.RegisterString(&m.quietHoursEndSpec, milestoneOptQuietHoursEnd, "", "The wall-clock end of the quiet-hours window (e.g. 07:00). Equal start and end disables quiet hours.")
+	opts.RegisterString(&m.quietHoursTimezone, milestoneOptQuietHoursTimezone, "", "The IANA timezone (e.g. Europe/Warsaw) in which the quiet-hours window is evaluated. Empty uses UTC.")
+	opts.RegisterString(&m.inProgressModesSpec, milestoneOptInProgressModes, fmt.Sprintf("%s,%s", milestoneModeSlush, milestoneModeFreeze), "A comma-separated list of modes in which blockers are required to carry the in-progress label.")
+	opts.RegisterStringSlice(&m.inProgressLabels, milestoneOptInProgressLabels, []string{statusInProgressLabel}, fmt.Sprintf("A comma-separated list of labels any of which satisfies the in-progress requirement, for teams that use e.g. 'status/started' instead of %q.", statusInProgressLabel))
+	opts.RegisterString(&m.removalMode, milestoneOptRemovalMode, milestoneRemovalModeClear, fmt.Sprintf("How issues are removed from the milestone. One of %v. %q applies the removal label and comment but leaves the milestone in place for manual removal.", milestoneRemovalModes.List(), milestoneRemovalModeLabelOnly))
+	opts.RegisterBool(&m.closeOnFinalRemoval, milestoneOptCloseOnFinalRemoval, false, "Whether the active milestone is the final one of its release, in which case issues removed from it are closed with an explanatory comment instead of merely losing the milestone.")
+	opts.RegisterBool(&m.requireBlockerApproval, milestoneOptRequireBlockerApproval, false, "Whether the approval requirement is enforced on blocker issues too. By default blockers are warned about missing approval but never removed.")
+	opts.RegisterDuration(&m.blockerApprovalGracePeriod, milestoneOptBlockerApprovalGracePeriod, 0, "The grace period for applying the approved label to a blocker before it is removed from the milestone. Zero warns indefinitely without removing.")
+	opts.RegisterBool(&m.requireBlockerAssignee, milestoneOptRequireBlockerAssignee, false, "Whether blockers with no assignee are warned about so that triage assigns an owner responsible for updates.")
+	opts.RegisterBool(&m.useIssueTypes, milestoneOptUseIssueTypes, false, "Whether a native github issue type should satisfy the kind label requirement for issues that have no kind label.")
+	opts.RegisterString(&m.sigMentionTemplate, milestoneOptSigMentionTemplate, defaultSigMentionTemplate, "The format string used to mention a sig in notifications. '%s' is replaced with the sig name.")
+	opts.RegisterString(&m.sigMentionOverridesSpec, milestoneOptSigMentionOverrides, "", "Per-sig mention handles overriding the mention template, in the form 'sig/architecture=@kubernetes/sig-architecture,...'. Useful for umbrella sigs whose team name does not follow the template's suffix.")
+	opts.RegisterString(&m.helpBaseURL, milestoneOptHelpBaseURL, defaultHelpBaseURL, "The base URL of the contributor documentation linked from the notification footer. Override for github enterprise installs.")
+	opts.RegisterString(&m.sigHelpURLsSpec, milestoneOptSigHelpURLs, "", "Per-sig milestone guideline links added to the notification footer in the form 'sig/node=https://url,sig/docs=https://url'.")
+	opts.RegisterBool(&m.mentionSigsWhenUnassigned, milestoneOptMentionSigsWhenUnassigned, true, "Whether notifications on an issue with no author or assignees should mention the sig leads instead of nobody.")
+	return nil
+}
+
+// MilestoneMaintainerSpec collects the tunables needed to construct a
+// maintainer directly, bypassing the options framework. Zero values
+// are replaced with the same defaults that option registration would
+// apply.
+type MilestoneMaintainerSpec struct {
+	BotName              string
+	ActiveMilestone      string
+	Mode                 string
+	FreezeDate           string
+	WarningInterval      time.Duration
+	LabelGracePeriod     time.Duration
+	ApprovalGracePeriod  time.Duration
+	SlushUpdateInterval  time.Duration
+	FreezeUpdateInterval time.Duration
+	GracePeriodFloor     time.Duration
+	AtRiskPercent        int
+}
+
+// NewTestMilestoneMaintainer constructs a fully-configured maintainer
+// from the given spec, for tests that want to exercise the state
+// machinery (e.g. via issueChangeConfig) without registering options.
+func NewTestMilestoneMaintainer(spec MilestoneMaintainerSpec) *MilestoneMaintainer {
+	if spec.Mode == "" {
+		spec.Mode = milestoneModeDev
+	}
+	if spec.WarningInterval == 0 {
+		spec.WarningInterval = day
+	}
+	if spec.LabelGracePeriod == 0 {
+		spec.LabelGracePeriod = 3 * day
+	}
+	if spec.ApprovalGracePeriod == 0 {
+		spec.ApprovalGracePeriod = 7 * day
+	}
+	if spec.SlushUpdateInterval == 0 {
+		spec.SlushUpdateInterval = 3 * day
+	}
+	if spec.FreezeUpdateInterval == 0 {
+		spec.FreezeUpdateInterval = day
+	}
+	if spec.GracePeriodFloor == 0 {
+		spec.GracePeriodFloor = day
+	}
+	if spec.AtRiskPercent == 0 {
+		spec.AtRiskPercent = 75
+	}
+	return &MilestoneMaintainer{
+		botName:              spec.BotName,
+		activeMilestone:      spec.ActiveMilestone,
+		mode:                 spec.Mode,
+		freezeDate:           spec.FreezeDate,
+		warningInterval:      spec.WarningInterval,
+		labelGracePeriod:     spec.LabelGracePeriod,
+		approvalGracePeriod:  spec.ApprovalGracePeriod,
+		slushUpdateInterval:  spec.SlushUpdateInterval,
+		freezeUpdateInterval: spec.FreezeUpdateInterval,
+		gracePeriodFloor:     spec.GracePeriodFloor,
+		atRiskPercent:        spec.AtRiskPercent,
+		sigMentionTemplate:   defaultSigMentionTemplate,
+		helpBaseURL:          defaultHelpBaseURL,
+		blockerLabel:         blockerLabel,
+		snoozeLabelPrefix:    defaultSnoozeLabelPrefix,
+	}
+}
+
+// effectiveMode returns the mode to enforce at the given time. When
+// the freeze date is a parseable timestamp that has passed, slush is
+// promoted to freeze without an operator having to flip the mode
+// option. Any other freeze date value is display-only.
+func (m *MilestoneMaintainer) effectiveMode(now time.Time) string {
+	if m.mode != milestoneModeSlush {
+		return m.mode
+	}
+	freezeTime, err := time.Parse(time.RFC3339, m.freezeDate)
+	if err != nil {
+		return m.mode
+	}
+	if !now.Before(freezeTime) {
+		return milestoneModeFreeze
+	}
+	return m.mode
+}
+
+// updateInterval returns the interval at which blocking issues must be
+// updated for the given mode. Callers pass the mode they are
+// evaluating so that previews of another mode use that mode's
+// interval rather than the current one.
+func (m *MilestoneMaintainer) updateInterval(mode string) time.Duration {
+	if mode == milestoneModeSlush {
+		return m.slushUpdateInterval
+	}
+	if mode == milestoneModeFreeze {
+		return m.freezeUpdateInterval
+	}
+	return 0
+}
+
+// audit records a mutation made to the given issue. Failures are
+// logged rather than propagated so that they never block mutations.
+func (m *MilestoneMaintainer) audit(obj *github.MungeObject, action string, oldLabels []string, newLabel string, state milestoneState) {
+	sink := m.auditSink
+	if sink == nil {
+		sink = glogAuditSink{}
+	}
+	record := milestoneAuditRecord{
+		Issue:     obj.Number(),
+		Action:    action,
+		OldLabels: oldLabels,
+		NewLabel:  newLabel,
+		State:     state.String(),
+		Mode:      m.effectiveMode(m.now()),
+	}
+	if err := sink.Record(record); err != nil {
+		glog.Errorf("Unable to record audit record %+v: %v", record, err)
+	}
+}
+
+// milestoneLogger emits structured JSON records with issue-scoped
+// fields, so that operators can filter the munger's decisions for a
+// single issue.
+type milestoneLogger struct {
+	out io.Writer
+}
+
+// newMilestoneLogger returns a logger writing JSON records to the
+// given writer, or to glog when the writer is nil.
+func newMilestoneLogger(out io.Writer) *milestoneLogger {
+	return &milestoneLogger{out: out}
+}
+
+// Log writes a single JSON record with the given message and fields.
+func (l *milestoneLogger) Log(message string, fields map[string]interface{}) {
+	record := map[string]interface{}{"message": message}
+	for k, v := range fields {
+		record[k] = v
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		glog.Errorf("Failed to encode log record %q: %v", message, err)
+		return
+	}
+	if l.out != nil {
+		fmt.Fprintln(l.out, string(data))
+		return
+	}
+	glog.Info(string(data))
+}
+
+// milestoneMailer sends notification email to sig leads who prefer it
+// over github mentions. Delivery sits behind an interface so that an
+// SMTP relay, an internal service, or a test fake can be swapped in.
+type milestoneMailer interface {
+	Send(to, subject, body string) error
+}
+
+// smtpMailer delivers notification email through a plain SMTP relay,
+// configured with the milestone-smtp-* options. Deployments needing
+// authenticated delivery should front it with a local relay.
+type smtpMailer struct {
+	server string
+	from   string
+}
+
+// Send delivers a single message through the configured relay.
+func (s *smtpMailer) Send(to, subject, body string) error {
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\n", s.from, to, subject, body)
+	return smtp.SendMail(s.server, nil, s.from, []string{to}, []byte(message))
+}
+
+// emailNotification mirrors removal and approval notifications to the
+// email addresses configured for the issue's sigs. Delivery failures
+// are logged and never block the github actions.
+func (m *MilestoneMaintainer) emailNotification(obj *github.MungeObject, change *issueChange) {
+	if m.mailer == nil || len(m.sigEmails) == 0 {
+		return
+	}
+	if change.state != milestoneNeedsRemoval && change.state != milestoneNeedsApproval {
+		return
+	}
+	subject := fmt.Sprintf("[%s] Issue #%d: %s", m.activeMilestoneFor(obj), obj.Number(), change.notification.Arguments)
+	for _, sigLabel := range m.effectiveSigLabels(obj.Issue.Labels) {
+		address, ok := m.sigEmails[sigLabel]
+		if !ok {
+			continue
+		}
+		if err := m.mailer.Send(address, subject, change.notification.Context); err != nil {
+			glog.Errorf("Failed to email %s about %d: %v", address, obj.Number(), err)
+		}
+	}
+}
+
+// logIssueChange records the outcome of evaluating an issue with the
+// fields needed to reconstruct the decision afterwards.
+func (m *MilestoneMaintainer) logIssueChange(obj *github.MungeObject, icc *issueChangeConfig) {
+	if m.logger == nil {
+		return
+	}
+	fields := map[string]interface{}{
+		"issue":    obj.Number(),
+		"state":    icc.state.String(),
+		"mode":     m.effectiveMode(m.now()),
+		"sections": icc.enabledSections.List(),
+	}
+	if icc.removeAfter != nil {
+		fields["removeAfter"] = icc.removeAfter.String()
+	}
+	m.logger.Log("issue evaluated", fields)
+}
+
+// currentStateLabels returns the milestone state labels currently
+// applied to the given issue.
+func currentStateLabels(obj *github.MungeObject) []string {
+	labels := []string{}
+	for _, stateLabel := range milestoneStateLabels {
+		if obj.HasLabel(stateLabel) {
+			labels = append(labels, stateLabel)
+		}
+	}
+	return labels
+}
+
+// updateIntervalForSigs returns the update interval under the given
+// mode for an issue with the given sig labels. The shortest per-sig
+// override applies; issues without an override fall back to the global
+// interval for the mode.
+func (m *MilestoneMaintainer) updateIntervalForSigs(mode string, sigLabels []string) time.Duration {
+	var overrides map[string]time.Duration
+	switch mode {
+	case milestoneModeSlush:
+		overrides = m.sigSlushIntervals
+	case milestoneModeFreeze:
+		overrides = m.sigFreezeIntervals
+	default:
+		return m.updateInterval(mode)
+	}
+	shortest := time.Duration(0)
+	for _, sigLabel := range sigLabels {
+		if override, ok := overrides[sigLabel]; ok && (shortest == 0 || override < shortest) {
+			shortest = override
+		}
+	}
+	if shortest == 0 {
+		return m.updateInterval(mode)
+	}
+	return shortest
+}
+
+// parseSigUpdateIntervals parses per-sig update interval overrides in
+// the form 'sig/node=24h,sig/docs=72h'.
+func parseSigUpdateIntervals(spec string) (map[string]time.Duration, error) {
+	intervals := map[string]time.Duration{}
+	if spec == "" {
+		return intervals, nil
+	}
+	for _, field := range strings.Split(spec, ",") {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("expected 'sig-label=duration', got %q", field)
+		}
+		sigLabel := strings.TrimSpace(parts[0])
+		if !strings.HasPrefix(sigLabel, sigLabelPrefix) {
+			return nil, fmt.Errorf("expected a label prefixed with %q, got %q", sigLabelPrefix, sigLabel)
+		}
+		duration, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse interval for %q: %v", sigLabel, err)
+		}
+		intervals[sigLabel] = duration
+	}
+	return intervals, nil
+}
+
+// hasInProgressLabel indicates whether the issue carries any of the
+// labels recognized as marking it in progress. An unset configuration
+// recognizes only statusInProgressLabel.
+func (m *MilestoneMaintainer) hasInProgressLabel(obj *github.MungeObject) bool {
+	labels := m.inProgressLabels
+	if len(labels) == 0 {
+		labels = []string{statusInProgressLabel}
+	}
+	for _, label := range labels {
+		if obj.HasLabel(label) {
+			return true
+		}
+	}
+	return false
+}
+
+// inProgressRequired indicates whether blockers are required to carry
+// the in-progress label in the given mode. The default requires it in
+// both slush and freeze.
+func (m *MilestoneMaintainer) inProgressRequired(mode string) bool {
+	if m.inProgressModes == nil {
+		return mode == milestoneModeSlush || mode == milestoneModeFreeze
+	}
+	return m.inProgressModes.Has(mode)
+}
+
+// parseModeList parses a comma-separated list of release cycle modes,
+// validating each against the known modes.
+func parseModeList(spec string) (sets.String, error) {
+	modes := sets.NewString()
+	if spec == "" {
+		return modes, nil
+	}
+	for _, field := range strings.Split(spec, ",") {
+		mode := strings.TrimSpace(field)
+		if !milestoneModes.Has(mode) {
+			return nil, fmt.Errorf("expected one of %v, got %q", milestoneModes.List(), mode)
+		}
+		modes.Insert(mode)
+	}
+	return modes, nil
+}
+
+// parseSigEmails parses a mapping from sig labels to email addresses
+// in the form 'sig/node=node-leads@example.com,...'.
+func parseSigEmails(spec string) (map[string]string, error) {
+	emails := map[string]string{}
+	if spec == "" {
+		return emails, nil
+	}
+	for _, field := range strings.Split(spec, ",") {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("expected 'sig-label=address', got %q", field)
+		}
+		sigLabel := strings.TrimSpace(parts[0])
+		if !strings.HasPrefix(sigLabel, sigLabelPrefix) {
+			return nil, fmt.Errorf("expected a label prefixed with %q, got %q", sigLabelPrefix, sigLabel)
+		}
+		address := strings.TrimSpace(parts[1])
+		if !strings.Contains(address, "@") {
+			return nil, fmt.Errorf("expected an email address for %q, got %q", sigLabel, address)
+		}
+		emails[sigLabel] = address
+	}
+	return emails, nil
+}
+
+// parseSigMentionOverrides parses per-sig mention handles in the form
+// 'sig/architecture=@kubernetes/sig-architecture,...'.
+func parseSigMentionOverrides(spec string) (map[string]string, error) {
+	overrides := map[string]string{}
+	if spec == "" {
+		return overrides, nil
+	}
+	for _, field := range strings.Split(spec, ",") {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("expected 'sig-label=@handle', got %q", field)
+		}
+		sigLabel := strings.TrimSpace(parts[0])
+		if !strings.HasPrefix(sigLabel, sigLabelPrefix) {
+			return nil, fmt.Errorf("expected a label prefixed with %q, got %q", sigLabelPrefix, sigLabel)
+		}
+		handle := strings.TrimSpace(parts[1])
+		if !strings.HasPrefix(handle, "@") {
+			return nil, fmt.Errorf("expected a mention handle starting with '@' for %q, got %q", sigLabel, handle)
+		}
+		overrides[sigLabel] = handle
+	}
+	return overrides, nil
+}
+
+// parseAreaSigMapping parses a mapping from area labels to owning sig
+// labels in the form 'area/kubelet=sig/node,area/docs=sig/docs'.
+func parseAreaSigMapping(spec string) (map[string]string, error) {
+	mapping := map[string]string{}
+	if spec == "" {
+		return mapping, nil
+	}
+	for _, field := range strings.Split(spec, ",") {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("expected 'area-label=sig-label', got %q", field)
+		}
+		areaLabel := strings.TrimSpace(parts[0])
+		if !strings.HasPrefix(areaLabel, areaLabelPrefix) {
+			return nil, fmt.Errorf("expected a label prefixed with %q, got %q", areaLabelPrefix, areaLabel)
+		}
+		sigLabel := strings.TrimSpace(parts[1])
+		if !strings.HasPrefix(sigLabel, sigLabelPrefix) {
+			return nil, fmt.Errorf("expected a label prefixed with %q, got %q", sigLabelPrefix, sigLabel)
+		}
+		mapping[areaLabel] = sigLabel
+	}
+	return mapping, nil
+}
+
+// parseSigHelpURLs parses per-sig help link overrides in the form
+// 'sig/node=https://url,sig/docs=https://url'.
+func parseSigHelpURLs(spec string) (map[string]string, error) {
+	helpURLs := map[string]string{}
+	if spec == "" {
+		return helpURLs, nil
+	}
+	for _, field := range strings.Split(spec, ",") {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("expected 'sig-label=url', got %q", field)
+		}
+		sigLabel := strings.TrimSpace(parts[0])
+		if !strings.HasPrefix(sigLabel, sigLabelPrefix) {
+			return nil, fmt.Errorf("expected a label prefixed with %q, got %q", sigLabelPrefix, sigLabel)
+		}
+		url := strings.TrimSpace(parts[1])
+		if url == "" {
+			return nil, fmt.Errorf("expected a url for %q", sigLabel)
+		}
+		helpURLs[sigLabel] = url
+	}
+	return helpURLs, nil
+}
+
+// parseSigAllowlist parses a comma-separated list of sig labels in the
+// form 'sig/node,sig/storage'.
+func parseSigAllowlist(spec string) (sets.String, error) {
+	allowlist := sets.NewString()
+	if spec == "" {
+		return allowlist, nil
+	}
+	for _, field := range strings.Split(spec, ",") {
+		sigLabel := strings.TrimSpace(field)
+		if !strings.HasPrefix(sigLabel, sigLabelPrefix) {
+			return nil, fmt.Errorf("expected a label prefixed with %q, got %q", sigLabelPrefix, sigLabel)
+		}
+		allowlist.Insert(sigLabel)
+	}
+	return allowlist, nil
+}
+
+// sigAllowed indicates whether the issue is in scope for the munger
+// according to the sig allowlist. An empty allowlist leaves all
+// milestone issues in scope.
+func (m *MilestoneMaintainer) sigAllowed(obj *github.MungeObject) bool {
+	if m.sigAllowlist.Len() == 0 {
+		return true
+	}
+	for _, sigLabel := range m.effectiveSigLabels(obj.Issue.Labels) {
+		if m.sigAllowlist.Has(sigLabel) {
+			return true
+		}
+	}
+	return false
+}
+
+// eligibleApprovers returns the sorted OWNERS approvers for the
+// issue's area labels. Issues without an area label, or whose areas
+// have no OWNERS mapping, yield an empty slice.
+func (m *MilestoneMaintainer) eligibleApprovers(obj *github.MungeObject) []string {
+	if m.features == nil || m.features.AreaApprovers == nil {
+		return nil
+	}
+	approverSet := sets.NewString()
+	for _, label := range obj.Issue.Labels {
+		if label.Name == nil || !strings.HasPrefix(*label.Name, areaLabelPrefix) {
+			continue
+		}
+		approverSet.Insert(m.features.AreaApprovers.Approvers(strings.TrimPrefix(*label.Name, areaLabelPrefix))...)
+	}
+	return approverSet.List()
+}
+
+// parseRemovalBlackout parses the start and end dates of the removal
+// blackout window. Both dates are inclusive; the returned end marks
+// the first instant after the window.
+func parseRemovalBlackout(startSpec, endSpec string) (time.Time, time.Time, error) {
+	if startSpec == "" && endSpec == "" {
+		return time.Time{}, time.Time{}, nil
+	}
+	if startSpec == "" || endSpec == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("both %q and %q must be set to enable a removal blackout", milestoneOptRemovalBlackoutStart, milestoneOptRemovalBlackoutEnd)
+	}
+	start, err := time.Parse(milestoneBlackoutDateFormat, startSpec)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("unable to parse %q: %v", milestoneOptRemovalBlackoutStart, err)
+	}
+	end, err := time.Parse(milestoneBlackoutDateFormat, endSpec)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("unable to parse %q: %v", milestoneOptRemovalBlackoutEnd, err)
+	}
+	end = end.Add(day)
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%q must not precede %q", milestoneOptRemovalBlackoutEnd, milestoneOptRemovalBlackoutStart)
+	}
+	return start, end, nil
+}
+
+// inRemovalBlackout indicates whether the given time falls in the
+// configured removal blackout window.
+func (m *MilestoneMaintainer) inRemovalBlackout(now time.Time) bool {
+	if m.removalBlackoutStart.IsZero() || m.removalBlackoutEnd.IsZero() {
+		return false
+	}
+	return !now.Before(m.removalBlackoutStart) && now.Before(m.removalBlackoutEnd)
+}
+
+// blackoutElapsed returns how much of the removal blackout window has
+// elapsed by the given time.
+func (m *MilestoneMaintainer) blackoutElapsed(now time.Time) time.Duration {
+	if m.removalBlackoutStart.IsZero() || m.removalBlackoutEnd.IsZero() || now.Before(m.removalBlackoutStart) {
+		return 0
+	}
+	end := m.removalBlackoutEnd
+	if now.Before(end) {
+		end = now
+	}
+	return end.Sub(m.removalBlackoutStart)
+}
+
+// clampGracePeriod returns the given grace period raised to the
+// configured floor, so that an issue can never accrue a negative
+// remaining grace in the same loop that applied its warning label.
+func (m *MilestoneMaintainer) clampGracePeriod(gracePeriod time.Duration) time.Duration {
+	if gracePeriod < m.gracePeriodFloor {
+		return m.gracePeriodFloor
+	}
+	return gracePeriod
+}
+
+// effectiveSigLabels returns the issue's sig labels, falling back to
+// the sigs derived from its area labels through the configured mapping
+// when no direct sig labels exist.
+func (m *MilestoneMaintainer) effectiveSigLabels(labels []githubapi.Label) []string {
+	sigLabels := sigLabelNames(labels)
+	if len(sigLabels) > 0 || len(m.areaSigMap) == 0 {
+		return sigLabels
+	}
+	derived := []string{}
+	seen := sets.NewString()
+	for _, label := range labels {
+		if label.Name == nil {
+			continue
+		}
+		sigLabel, ok := m.areaSigMap[*label.Name]
+		if !ok || seen.Has(sigLabel) {
+			continue
+		}
+		seen.Insert(sigLabel)
+		derived = append(derived, sigLabel)
+	}
+	return derived
+}
+
+// labelsWithDerivedSigs returns the issue's labels, augmented with the
+// sig labels derived from its area labels when no direct sig labels
+// are present. The synthetic labels let checkLabels treat a mapped
+// area as sig ownership.
+func (m *MilestoneMaintainer) labelsWithDerivedSigs(labels []githubapi.Label) []githubapi.Label {
+	if len(m.areaSigMap) == 0 || len(sigLabelNames(labels)) > 0 {
+		return labels
+	}
+	augmented := append([]githubapi.Label{}, labels...)
+	for _, sigLabel := range m.effectiveSigLabels(labels) {
+		name := sigLabel
+		augmented = append(augmented, githubapi.Label{Name: &name})
+	}
+	return augmented
+}
+
+// linkedOpenPRs returns the numbers of the open pull requests
+// referencing the issue, when the label summary is configured to list
+// them. Returns nil when the option is off or the timeline lookup
+// fails.
+func (m *MilestoneMaintainer) linkedOpenPRs(obj *github.MungeObject) []int {
+	if !m.summarizeLinkedPRs {
+		return nil
+	}
+	prs, ok := obj.OpenLinkedPRs()
+	if !ok {
+		return nil
+	}
+	return prs
+}
+
+// validateGracePeriods checks that each grace period is at least one
+// warning interval long. A shorter grace period can remove an issue
+// from the milestone before its warning is ever repeated. The
+// inconsistency is a logged warning by default and an error when
+// strict validation is enabled.
+func (m *MilestoneMaintainer) validateGracePeriods() error {
+	gracePeriods := []struct {
+		option string
+		value  time.Duration
+	}{
+		{milestoneOptLabelGracePeriod, m.labelGracePeriod},
+		{milestoneOptApprovalGracePeriod, m.approvalGracePeriod},
+	}
+	for _, gracePeriod := range gracePeriods {
+		if gracePeriod.value >= m.warningInterval {
+			continue
+		}
+		err := fmt.Errorf("option %q (%s) is shorter than %q (%s); issues may be removed before a repeat warning is posted", gracePeriod.option, gracePeriod.value, milestoneOptWarningInterval, m.warningInterval)
+		if m.strictConfigValidation {
+			return err
+		}
+		glog.Warningf("%v", err)
+	}
+	return nil
+}
+
+// atRiskThreshold returns the duration since the last update after
+// which a blocking issue is considered at risk of missing its update
+// interval, or 0 if the at-risk state is disabled.
+func (m *MilestoneMaintainer) atRiskThreshold(updateInterval time.Duration) time.Duration {
+	if m.atRiskPercent <= 0 || m.atRiskPercent >= 100 {
+		return 0
+	}
+	return updateInterval * time.Duration(m.atRiskPercent) / 100
+}
+
+// detailSection returns the help footer appended to notifications,
+// with links rooted at the configured help-docs base URL. Sigs with a
+// configured guideline link have it added for their labeled issues.
+func (m *MilestoneMaintainer) detailSection(sigLabels []string) string {
+	baseURL := strings.TrimSuffix(m.helpBaseURL, "/")
+	detailTemplate := m.detailTemplateOverride
+	if detailTemplate == "" {
+		detailTemplate = milestoneDetailTemplate
+	}
+	section := fmt.Sprintf(detailTemplate, baseURL, baseURL)
+
+	links := []string{}
+	for _, sigLabel := range sigLabels {
+		if url, ok := m.sigHelpURLs[sigLabel]; ok {
+			links = append(links, fmt.Sprintf(` <li><a href="%s">Milestone guidelines for %s</a></li>`, url, strings.TrimPrefix(sigLabel, sigLabelPrefix)))
+		}
+	}
+	if len(links) == 0 {
+		return section
+	}
+	// Sig-specific links extend the generic list when the template has
+	// one, and trail the section otherwise.
+	if index := strings.LastIndex(section, "</ul>"); index >= 0 {
+		return section[:index] + strings.Join(links, "\n") + "\n" + section[index:]
+	}
+	return section + strings.Join(links, "\n") + "\n"
+}
+
+// Munge is the workhorse that will actually make updates to the issue.
+// The option fields are stable for the duration of a single munge:
+// option reloads take the write side of optionsLock.
+func (m *MilestoneMaintainer) Munge(obj *github.MungeObject) {
+	m.optionsLock.RLock()
+	defer m.optionsLock.RUnlock()
+
+	if m.useGraphQL && !obj.PrefetchGraphQL() {
+		glog.Warningf("GraphQL prefetch failed for %d; falling back to lazy REST fetches", obj.Number())
+	}
+
+	activeMilestone := m.activeMilestoneFor(obj)
+
+	// A removed issue no longer carries the active milestone, so the
+	// restore command has to be considered before the ignore checks.
+	if m.restoreRemoved(obj, activeMilestone) {
+		return
+	}
+
+	// An issue a human reassigned to another milestone is otherwise
+	// ignored below, leaving the maintainer's state labels orphaned on
+	// it.
+	if m.stripOrphanedStateLabels(obj, activeMilestone) {
+		return
+	}
+
+	if ignoreObject(obj, activeMilestone, m.titlePattern, m.autoReaddBlockers, m.blockerLabel, m.cleanupClosed, m.exemptAuthors, m.ignoreTitlePrefixes) {
+		return
+	}
+
+	if issueClosed(obj) {
+		// A closed issue only has stale state labels stripped; the
+		// comment stream is left untouched.
+		if milestone, ok := obj.ReleaseMilestone(); ok && milestone == activeMilestone {
+			m.updateMilestoneStateLabel(obj, "")
+		}
+		return
+	}
+
+	if milestoneCacheStale(obj) {
+		return
+	}
+
+	if !m.sigAllowed(obj) {
+		return
+	}
+
+	m.reconcileStateLabels(obj)
+
+	if milestone, ok := obj.ReleaseMilestone(); ok && milestone != activeMilestone {
+		// An issue outside the active milestone is only considered
+		// when it is a removed blocker eligible for re-add.
+		m.readdRemovedBlocker(obj)
+		return
+	}
+
+	m.applyStatusCommands(obj)
+	m.applyApprovalCommands(obj)
+	m.applyPriorityCommands(obj)
+	m.applyDefaultPriority(obj)
+	m.handleApprovalRequests(obj)
+	m.handleStatusQueries(obj)
+	m.syncStaleLabel(obj)
+
+	change := m.issueChange(obj)
+	if change == nil {
+		return
+	}
+	m.recordIssueState(obj, change.state)
+
+	// Acknowledge the transition out of a warning state with a
+	// one-time resolved notification, before the warning state label
+	// is removed below.
+	suppressSummary := m.suppressSummary && change.state == milestoneCurrent
+	if change.state == milestoneCurrent && m.resolvedWarningState(obj) {
+		change.notification = m.resolvedNotification(obj)
+		// Resolved notifications are not summary-only and are still
+		// posted when the summary is suppressed.
+		suppressSummary = false
+	}
+
+	oldLabels := currentStateLabels(obj)
+	if !m.updateMilestoneStateLabel(obj, change.label) {
+		return
+	}
+	stateLabelUnchanged := (len(oldLabels) == 0 && change.label == "") ||
+		(len(oldLabels) == 1 && oldLabels[0] == change.label)
+	if !stateLabelUnchanged {
+		m.audit(obj, "update-state-label", oldLabels, change.label, change.state)
+		m.notifyStateWebhook(obj, oldLabels, change.state)
+	}
+	m.syncAttentionColumn(obj, change.state)
+
+	if m.labelsOnly {
+		// Maintain labels and the milestone but leave the comment
+		// stream untouched.
+		if change.state == milestoneNeedsRemoval && !m.clearRemovedMilestone(obj, change.state) {
+			return
+		}
+		return
+	}
+
+	if suppressSummary {
+		return
+	}
+
+	if m.inQuietHours(m.now()) {
+		// Labels and the milestone were already maintained above; the
+		// notification posts on the first loop after the window closes.
+		glog.Infof("Deferring the notification for %d until quiet hours end", obj.Number())
+		return
+	}
+
+	comment, ok := latestNotificationComment(obj, m.botNames())
+	if !ok {
+		return
+	}
+
+	// A resolved notification is a terminal acknowledgement; do not
+	// replace it with the regular summary for the current state.
+	if change.state == milestoneCurrent && comment != nil {
+		if old := stripRepeatMarker(c.ParseNotification(comment)); old != nil && old.Arguments == milestoneResolvedTitle {
+			return
+		}
+	}
+	commentInterval := change.commentInterval
+	if commentInterval != nil && m.warningBackoff {
+		effectiveInterval := m.backoffInterval(comment)
+		commentInterval = &effectiveInterval
+	}
+	if !notificationIsCurrent(change.notification, comment, commentInterval, m.now()) {
+		if m.inJitterHold(obj, m.now()) {
+			// Labels and the milestone state were already maintained
+			// above; the notification posts once the issue's slot in the
+			// jitter window arrives.
+			glog.Infof("Deferring the notification for %d until its jitter slot", obj.Number())
+			return
+		}
+		if m.warningBackoff && change.commentInterval != nil {
+			// Record how often the notification has been posted so that
+			// the effective interval can back off. A repost of the same
+			// warning with refreshed content (e.g. an updated countdown
+			// or escalated wording) still counts as a repeat; only a
+			// different warning resets the count.
+			if old := c.ParseNotification(comment); old != nil && old.Arguments == change.notification.Arguments {
+				repeat := notificationRepeatCount(comment) + 1
+				change.notification.Context += "\n" + fmt.Sprintf(milestoneWarningRepeatMarker, repeat)
+			}
+		}
+		if comment != nil && m.editNotifications && !notificationsMatch(change.notification, comment) {
+			// The notification content changed; updating the existing
+			// comment in place preserves replies and reactions.
+			if err := m.retryMutation("editing notification", func() error {
+				return obj.EditComment(comment.Source.(*githubapi.IssueComment), change.notification.String())
+			}); err != nil {
+				return
+			}
+			m.audit(obj, "edit-notification", nil, "", change.state)
+		} else {
+			if comment != nil {
+				if err := m.retryMutation("deleting notification", func() error {
+					return obj.DeleteComment(comment.Source.(*githubapi.IssueComment))
+				}); err != nil {
+					return
+				}
+			}
+			if err := m.retryMutation("posting notification", func() error {
+				return m.postNotification(obj, change.notification)
+			}); err != nil {
+				return
+			}
+			m.audit(obj, "post-notification", nil, "", change.state)
+		}
+		m.emailNotification(obj, change)
+
+		if change.state == milestoneNeedsRemoval && !m.clearRemovedMilestone(obj, change.state) {
+			return
+		}
+	}
+}
+
+// clearRemovedMilestone clears the milestone of an issue slated for
+// removal, unless the label-only removal mode leaves the milestone in
+// place for manual handling. When the active milestone is flagged as
+// the final one of its release, the issue is additionally closed with
+// an explanatory comment. Returns false when a mutation failed.
+func (m *MilestoneMaintainer) clearRemovedMilestone(obj *github.MungeObject, state milestoneState) bool {
+	if m.removalMode == milestoneRemovalModeLabelOnly {
+		return true
+	}
+	milestone := m.activeMilestoneFor(obj)
+	if err := m.retryMutation("clearing milestone", func() error {
+		return obj.ClearMilestone()
+	}); err != nil {
+		return false
+	}
+	m.audit(obj, "clear-milestone", nil, "", state)
+	if m.closeOnFinalRemoval {
+		if err := m.retryMutation("posting close comment", func() error {
+			return obj.WriteComment(fmt.Sprintf(milestoneFinalRemovalComment, milestone))
+		}); err != nil {
+			return false
+		}
+		if err := m.retryMutation("closing issue", obj.CloseIssue); err != nil {
+			return false
+		}
+		m.audit(obj, "close-issue", nil, "", state)
+	}
+	return true
+}
+
+// stripOrphanedStateLabels removes the maintainer's state labels from
+// an issue that sits in a different milestone than the active one,
+// where they would otherwise linger forever. Removed issues (with no
+// milestone at all) are left alone so that the removed label keeps
+// supporting the restore and re-add flows. Returns true when the issue
+// was handled as an orphan.
+func (m *MilestoneMaintainer) stripOrphanedStateLabels(obj *github.MungeObject, activeMilestone string) bool {
+	if !m.cleanupOrphaned {
+		return false
+	}
+	milestone, ok := obj.ReleaseMilestone()
+	if !ok || milestone == "" || milestone == activeMilestone {
+		return false
+	}
+	stateLabels := currentStateLabels(obj)
+	if len(stateLabels) == 0 {
+		return false
+	}
+	glog.Infof("Stripping orphaned state labels %v from %d (milestone %q)", stateLabels, obj.Number(), milestone)
+	for _, stateLabel := range stateLabels {
+		if err := m.retryMutation(fmt.Sprintf("removing orphaned label %q", stateLabel), func() error {
+			return obj.RemoveLabel(stateLabel)
+		}); err != nil {
+			return true
+		}
+	}
+	m.audit(obj, "strip-orphaned-labels", stateLabels, "", milestoneCurrent)
+	return true
+}
+
+// notificationKey derives the idempotency key embedded in a
+// notification from its state and content.
+func notificationKey(state milestoneState, body string) string {
+	hash := fnv.New64a()
+	hash.Write([]byte(state.String()))
+	hash.Write([]byte(body))
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
+
+// notificationKeyIn extracts the idempotency key embedded in a
+// notification body, or "" when none is present.
+func notificationKeyIn(body string) string {
+	fields := strings.SplitN(milestoneNotificationKeyMarker, "%s", 2)
+	start := strings.Index(body, fields[0])
+	if start < 0 {
+		return ""
+	}
+	rest := body[start+len(fields[0]):]
+	end := strings.Index(rest, fields[1])
+	if end < 0 {
+		return ""
+	}
+	return rest[:end]
+}
+
+// postNotification posts the given notification unless a comment
+// carrying the same idempotency key is already present, guarding
+// against a double post when two loops race on the same stale
+// notification.
+func (m *MilestoneMaintainer) postNotification(obj *github.MungeObject, notification *c.Notification) error {
+	if key := notificationKeyIn(notification.String()); key != "" {
+		if comments, ok := obj.RecentComments(); ok {
+			marker := fmt.Sprintf(milestoneNotificationKeyMarker, key)
+			for _, comment := range comments {
+				if comment.Body != nil && strings.Contains(*comment.Body, marker) {
+					glog.Infof("Skipping notification on %d: an identical notification (key %s) is already posted", obj.Number(), key)
+					return nil
+				}
+			}
+		}
+	}
+	return notification.Post(obj)
+}
+
+// issueChange computes the changes required to modify the state of an issue.
+func (m *MilestoneMaintainer) issueChange(obj *github.MungeObject) *issueChange {
+	if until := m.snoozedUntil(obj); until != nil && m.now().Before(*until) {
+		glog.Infof("Issue %d is snoozed until %s; taking no action", obj.Number(), until.Format(milestoneBlackoutDateFormat))
+		return nil
+	}
+
+	icc := m.issueChangeConfig(obj)
+	if icc == nil {
+		return nil
+	}
+	m.logIssueChange(obj, icc)
+
+	messageBody := icc.messageBody()
+	if messageBody == nil {
+		// A template failure would otherwise silently drop the issue;
+		// surface it so that a broken override gets noticed.
+		glog.Errorf("Failed to render the notification template for issue %d (sections %v)", obj.Number(), icc.enabledSections.List())
+		m.recordTemplateError()
+		return nil
+	}
+
+	stateConfig := milestoneStateConfigs[icc.state]
+
+	mentions := mungerutil.GetIssueUsers(obj.Issue).AllUsers().Mention().Join()
+	if stateConfig.notifySIGs && !m.noSigMentions {
+		sigMentions := icc.sigMentions()
+		if len(sigMentions) > 0 {
+			if mentions == "" {
+				mentions = sigMentions
+			} else {
+				mentions = fmt.Sprintf("%s %s", mentions, sigMentions)
+			}
+		}
+	} else if mentions == "" && m.mentionSigsWhenUnassigned && !m.noSigMentions {
+		// Nobody to ping directly; fall back to the sig leads so the
+		// notification still reaches someone actionable.
+		mentions = icc.sigMentions()
+	}
+
+	// The release team is CC'd on removals so that milestone churn is
+	// visible to them.
+	if icc.state == milestoneNeedsRemoval && len(m.removalCC) > 0 {
+		ccMentions := []string{}
+		for _, handle := range m.removalCC {
+			ccMentions = append(ccMentions, fmt.Sprintf("@%s", strings.TrimPrefix(handle, "@")))
+		}
+		cc := fmt.Sprintf("cc %s", strings.Join(ccMentions, " "))
+		if mentions == "" {
+			mentions = cc
+		} else {
+			mentions = fmt.Sprintf("%s %s", mentions, cc)
+		}
+	}
+
+	message := fmt.Sprintf("%s\n\n%s\n%s", mentions, *messageBody, m.detailSection(icc.sigLabels))
+	message = fmt.Sprintf("%s\n%s", message, fmt.Sprintf(milestoneNotificationKeyMarker, notificationKey(icc.state, message)))
+
+	var commentInterval *time.Duration
+	if stateConfig.warnOnInterval {
+		commentInterval = &m.warningInterval
+	}
+
+	return &issueChange{
+		notification:    c.NewNotification(milestoneNotifierName, expandNotificationTitle(stateConfig.title, icc.templateArguments), message),
+		label:           stateConfig.label,
+		state:           icc.state,
+		commentInterval: commentInterval,
+	}
+}
+
+// issueChangeConfig is the config required to change an issue (via
+// labels and/or a notification) to reflect its state in relation to
+// the active milestone.
+type issueChangeConfig struct {
+	state milestoneState
+	// states records every state the issue qualified for during
+	// evaluation; the reported state is the most severe of them.
+	states              []milestoneState
+	enabledSections     sets.String
+	sigLabels           []string
+	sigMentionTemplate  string
+	sigMentionOverrides map[string]string
+	messageTemplate     string
+	templateArguments   map[string]interface{}
+	// firstContact is true when the issue has never been sent a
+	// notification, selecting the softer first-contact wording.
+	firstContact bool
+	// eligibleApprovers are the OWNERS approvers for the issue's
+	// areas, named in approval warnings when known.
+	eligibleApprovers []string
+	// removalReason is the machine-readable code embedded in removal
+	// notifications so that downstream tooling can parse why the issue
+	// was removed.
+	removalReason string
+	// removeAfter is the grace time remaining when one applied to the
+	// evaluation, recorded for structured logging.
+	removeAfter *time.Duration
+}
+
+// issueChangeConfig computes the configuration required to determine
+// the changes to make to an issue so that it reflects the milestone
+// process.
+func (m *MilestoneMaintainer) issueChangeConfig(obj *github.MungeObject) *issueChangeConfig {
+	return m.issueChangeConfigForMode(obj, m.effectiveMode(m.now()))
+}
+
+// issueChangeConfigForMode computes the issue change config under the
+// given mode rather than the configured one, so that previews can
+// render other modes without mutating the munger.
+func (m *MilestoneMaintainer) issueChangeConfigForMode(obj *github.MungeObject, mode string) *issueChangeConfig {
+	now := m.now()
+	updateInterval := m.updateIntervalForSigs(mode, m.effectiveSigLabels(obj.Issue.Labels))
+
+	objType := "issue"
+	milestone := fmt.Sprintf("%s milestone", m.activeMilestoneFor(obj))
+
+	icc := &issueChangeConfig{
+		enabledSections:     sets.NewString(),
+		sigLabels:           []string{},
+		sigMentionTemplate:  m.sigMentionTemplate,
+		sigMentionOverrides: m.sigMentionOverrides,
+		messageTemplate:     m.messageTemplateOverride,
+		templateArguments: map[string]interface{}{
+			"approvalGracePeriod": durationToDaysAndHours(m.approvalGracePeriod),
+			"approvedLabel":       quoteLabel(statusApprovedLabel),
+			"blockerLabel":        quoteLabel(m.blockerLabel),
+			"freezeDate":          m.freezeDate,
+			"inProgressLabel":     quoteLabel(statusInProgressLabel),
+			"labelGracePeriod":    durationToDaysAndHours(m.labelGracePeriod),
+			"milestone":           milestone,
+			"mode":                mode,
+			"objType":             objType,
+			"objTypePlural":       fmt.Sprintf("%ss", objType),
+			"updateInterval":      durationToDaysAndHours(updateInterval),
+		},
+	}
+
+	priorWarnings, ok := warningCount(obj, m.botNames())
+	if !ok {
+		return nil
+	}
+	// The first notification carries no ordinal; repeats remind the
+	// reader how many times they have been pinged. The softened
+	// first-contact wording is kept until the warning interval has
+	// elapsed so that posting the heads-up does not immediately make
+	// the body stale and trigger an escalated repost.
+	icc.templateArguments["warningNumber"] = ""
+	icc.firstContact = priorWarnings == 0 || (priorWarnings == 1 && m.firstContactIsCurrent(obj, now))
+	icc.eligibleApprovers = m.eligibleApprovers(obj)
+	if priorWarnings > 0 && !icc.firstContact {
+		icc.templateArguments["warningNumber"] = fmt.Sprintf(milestoneWarningCountTemplate, priorWarnings+1)
+	}
+
+	isBlocker := obj.HasLabel(m.blockerLabel)
+	exemptFromRemoval := m.removalExempt(obj)
+
+	// During a removal blackout, warnings continue but issues are not
+	// moved out of the milestone.
+	inBlackout := m.inRemovalBlackout(now)
+	graceExtension := time.Duration(0)
+	if m.blackoutPausesGrace {
+		// Pausing the grace clock errs on the lenient side: every
+		// grace period is extended by the elapsed portion of the
+		// blackout window.
+		graceExtension = m.blackoutElapsed(now)
+	}
+
+	// An authorized removal request takes effect immediately,
+	// bypassing any remaining grace period.
+	if requester, ok := m.removalRequested(obj); ok {
+		icc.removeByRequest(requester)
+		return m.resolveState(icc)
+	}
+
+	issueType := ""
+	if m.useIssueTypes {
+		fetchedType, ok := obj.IssueType()
+		if !ok {
+			return nil
+		}
+		issueType = fetchedType
+	}
+
+	if kind, priority, sigs, labelErrors := checkLabels(m.labelsWithDerivedSigs(obj.Issue.Labels), issueType, m.singleSigOwner, m.extraLabelGroups); len(labelErrors) == 0 {
+		icc.summarizeLabels(objType, kind, priority, sigs, m.linkedOpenPRs(obj))
+		approved := obj.HasLabel(statusApprovedLabel)
+		if !approved && m.approvalFromReview {
+			reviewApproved, ok := m.approvedViaReview(obj)
+			if !ok {
+				return nil
+			}
+			approved = reviewApproved
+		}
+		if !approved {
+			// Blockers are only warned, never removed, unless blocker
+			// approval is enforced with a finite grace period of its
+			// own.
+			gracePeriod := m.approvalGracePeriod
+			warnOnly := isBlocker || exemptFromRemoval
+			if isBlocker && m.requireBlockerApproval {
+				warnOnly = m.blockerApprovalGracePeriod <= 0
+				gracePeriod = m.blockerApprovalGracePeriod
+			}
+			if warnOnly {
+				icc.warnUnapproved(nil, objType, milestone)
+			} else {
+				removeAfter, ok := gracePeriodRemaining(obj, m.botNames(), milestoneNeedsApprovalLabel, m.clampGracePeriod(gracePeriod)+graceExtension, now, false, m.businessDayGrace, m.holidays, m.graceFromFirstWarning)
+				if !ok {
+					return nil
+				}
+				if removeAfter == nil || *removeAfter >= 0 {
+					icc.warnUnapproved(removeAfter, objType, milestone)
+				} else if inBlackout {
+					icc.warnUnapproved(nil, objType, milestone)
+				} else {
+					icc.removeUnapproved()
+				}
+			}
+			return m.resolveState(icc)
+		}
+
+		if mode == milestoneModeDev {
+			// Status and updates are not required during development
+			return m.resolveState(icc)
+		}
+
+		if m.requireDesignDoc && obj.HasLabel(featureKindLabel) && !m.hasDesignDocLink(obj) {
+			icc.warnMissingDesignDoc()
+		}
+
+		if isBlocker {
+			if !m.hasInProgressLabel(obj) && m.inProgressRequired(mode) {
+				icc.warnMissingInProgress()
+			}
+			if m.requireBlockerAssignee && !issueHasAssignee(obj.Issue) {
+				icc.warnMissingAssignee()
+			}
+			lastUpdateTime, lastUpdateURL, ok := findLastModificationTime(obj, m.botNames(), m.ignoredUpdateActors)
+			if !ok {
+				return nil
+			}
+			durationSinceUpdate := now.Sub(*lastUpdateTime)
+			if durationSinceUpdate > updateInterval {
+				icc.warnUpdateRequired(*lastUpdateTime, lastUpdateURL)
+			} else if mode == milestoneModeFreeze && m.requireETA && !m.latestUpdateHasETA(obj) {
+				// A recent update without an ETA does not settle the
+				// update requirement during freeze.
+				icc.warnMissingETA()
+			} else if atRiskAfter := m.atRiskThreshold(updateInterval); atRiskAfter > 0 && durationSinceUpdate > atRiskAfter {
+				icc.warnUpdateAtRisk(*lastUpdateTime, lastUpdateURL)
+			}
+			icc.warnUpdateInterval()
+		} else if mode == milestoneModeSlush || inBlackout {
+			icc.warnNonBlockerRemoval()
+		} else {
+			keep := exemptFromRemoval
+			if !keep && m.keepWithOpenPR {
+				openPR, ok := obj.HasOpenLinkedPR()
+				if !ok {
+					return nil
+				}
+				keep = openPR
+			}
+			if !keep && m.releaseBranch != "" {
+				// A fix awaiting merge to the release branch blocks
+				// removal regardless of the broader open-PR policy.
+				pendingFix, ok := obj.HasOpenLinkedPRToBranch(m.releaseBranch)
+				if !ok {
+					return nil
+				}
+				keep = pendingFix
+			}
+			if keep {
+				// An open linked pull request suggests the issue is
+				// still being worked on; keep it in the milestone but
+				// continue warning.
+				icc.warnNonBlockerRemoval()
+			} else {
+				icc.removeNonBlocker()
+			}
+		}
+	} else {
+		// An issue with no recognized labels at all is untriaged
+		// rather than incompletely labeled, and the grace clock
+		// tracks the triage label instead.
+		needsTriage := !hasAnyRecognizedLabel(obj.Issue.Labels)
+		graceLabel := milestoneLabelsIncompleteLabel
+		if needsTriage {
+			graceLabel = milestoneNeedsTriageLabel
+		}
+		removeAfter, ok := gracePeriodRemaining(obj, m.botNames(), graceLabel, m.clampGracePeriod(m.labelGracePeriod)+graceExtension, now, isBlocker || exemptFromRemoval, m.businessDayGrace, m.holidays, m.graceFromFirstWarning)
+		if !ok {
+			return nil
+		}
+		if removeAfter == nil || *removeAfter >= 0 {
+			if needsTriage {
+				icc.warnNeedsTriage(labelErrors)
+			} else {
+				icc.warnIncompleteLabels(removeAfter, labelErrors, objType, milestone)
+			}
+		} else if inBlackout {
+			if needsTriage {
+				icc.warnNeedsTriage(labelErrors)
+			} else {
+				icc.warnIncompleteLabels(nil, labelErrors, objType, milestone)
+			}
+		} else {
+			icc.removeIncompleteLabels(labelErrors)
+		}
+	}
+	return m.resolveState(icc)
+}
+
+// parseQuietHours validates and compiles the quiet-hours options.
+func (m *MilestoneMaintainer) parseQuietHours() error {
+	start, err := parseTimeOfDay(m.quietHoursStartSpec)
+	if err != nil {
+		return fmt.Errorf("unable to parse %q: %v", milestoneOptQuietHoursStart, err)
+	}
+	end, err := parseTimeOfDay(m.quietHoursEndSpec)
+	if err != nil {
+		return fmt.Errorf("unable to parse %q: %v", milestoneOptQuietHoursEnd, err)
+	}
+	var location *time.Location
+	if m.quietHoursTimezone != "" {
+		location, err = time.LoadLocation(m.quietHoursTimezone)
+		if err != nil {
+			return fmt.Errorf("unable to parse %q: %v", milestoneOptQuietHoursTimezone, err)
+		}
+	}
+	m.quietHoursStart = start
+	m.quietHoursEnd = end
+	m.quietHoursLocation = location
+	return nil
+}
+
+// parseTimeOfDay parses a wall-clock time in the form "15:04" into
+// minutes since midnight. An empty value parses as zero.
+func parseTimeOfDay(spec string) (int, error) {
+	if spec == "" {
+		return 0, nil
+	}
+	parsed, err := time.Parse("15:04", spec)
+	if err != nil {
+		return 0, err
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+// inQuietHours indicates whether the given time falls within the
+// configured quiet-hours window, during which notification comments
+// are withheld. A window whose start is later than its end spans
+// midnight.
+func (m *MilestoneMaintainer) inQuietHours(now time.Time) bool {
+	if m.quietHoursStart == m.quietHoursEnd {
+		return false
+	}
+	if m.quietHoursLocation != nil {
+		now = now.In(m.quietHoursLocation)
+	}
+	minutes := now.Hour()*60 + now.Minute()
+	if m.quietHoursStart < m.quietHoursEnd {
+		return minutes >= m.quietHoursStart && minutes < m.quietHoursEnd
+	}
+	return minutes >= m.quietHoursStart || minutes < m.quietHoursEnd
+}
+
+// notificationJitterSlot derives the issue's deterministic offset
+// within the jitter window from its number.
+func notificationJitterSlot(number int, window time.Duration) time.Duration {
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%d", number)
+	return time.Duration(hash.Sum64() % uint64(window))
+}
+
+// inJitterHold indicates whether the notification for the issue should
+// wait for the issue's slot in the jitter window. Slots spread a batch
+// of issues that all became stale in the same loop across subsequent
+// loops, up to the configured window, instead of paging every sig at
+// once. Being deterministic in the issue number, an issue's slot is
+// stable across loops and restarts without having to track what was
+// notified.
+func (m *MilestoneMaintainer) inJitterHold(obj *github.MungeObject, now time.Time) bool {
+	if m.notificationJitter <= 0 {
+		return false
+	}
+	phase := now.Sub(now.Truncate(m.notificationJitter))
+	return phase < notificationJitterSlot(obj.Number(), m.notificationJitter)
+}
+
+// defaultStateSeverity orders milestone states from most to least
+// severe. It reproduces the outcomes of the historical last-assignment
+// behavior when an issue qualifies for several states at once.
+var defaultStateSeverity = []milestoneState{
+	milestoneNeedsRemoval,
+	milestoneNeedsAttention,
+	milestoneAtRisk,
+	milestoneNeedsTriage,
+	milestoneNeedsLabeling,
+	milestoneNeedsApproval,
+	milestoneCurrent,
+}
+
+// resolveState reduces the states the issue qualified for to the most
+// severe one according to the configured severity ordering.
+func (m *MilestoneMaintainer) resolveState(icc *issueChangeConfig) *issueChangeConfig {
+	ordering := m.stateSeverity
+	if len(ordering) == 0 {
+		ordering = defaultStateSeverity
+	}
+	for _, state := range ordering {
+		for _, seen := range icc.states {
+			if seen == state {
+				icc.state = state
+				return icc
+			}
+		}
+	}
+	return icc
+}
+
+// parseStateSeverity parses a comma-separated list of state names into
+// a severity ordering, most severe first.
+func parseStateSeverity(spec string) ([]milestoneState, error) {
+	states := []milestoneState{}
+	if spec == "" {
+		return states, nil
+	}
+	for _, field := range strings.Split(spec, ",") {
+		name := strings.TrimSpace(field)
+		state, ok := stateForName(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown milestone state %q", name)
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// stateForName returns the milestone state with the given name.
+func stateForName(name string) (milestoneState, bool) {
+	for state := range milestoneStateConfigs {
+		if state.String() == name {
+			return state, true
+		}
+	}
+	return milestoneCurrent, false
+}
+
+// expandNotificationTitle expands a state title as a template with the
+// same arguments used for the notification body, so that values like
+// the mode and milestone can be interpolated. Titles without template
+// actions pass through unchanged, as do titles that fail to render.
+func expandNotificationTitle(title string, templateArguments map[string]interface{}) string {
+	expanded := approvers.GenerateTemplateOrFail(title, "title", templateArguments)
+	if expanded == nil {
+		return title
+	}
+	return *expanded
+}
+
+func (icc *issueChangeConfig) messageBody() *string {
+	for _, sectionName := range icc.enabledSections.List() {
+		// Enable the sections of the template that have been
+		// configured for this issue.
+		icc.templateArguments[sectionName] = true
+	}
+	messageTemplate := icc.messageTemplate
+	if messageTemplate == "" {
+		messageTemplate = milestoneMessageTemplate
+		if icc.firstContact {
+			messageTemplate = milestoneFirstContactMessageTemplate
+		}
+	}
+	body := approvers.GenerateTemplateOrFail(messageTemplate, "message", icc.templateArguments)
+	if body != nil && len(icc.removalReason) > 0 {
+		reasoned := fmt.Sprintf("%s\n%s\n", *body, fmt.Sprintf(milestoneRemovalReasonMarker, icc.removalReason))
+		body = &reasoned
+	}
+	return body
+}
+
+func (icc *issueChangeConfig) enableSection(sectionName string) {
+	icc.enabledSections.Insert(sectionName)
+}
+
+// enterState records a state the issue qualifies for. Individual
+// sections no longer compete on assignment order; the state ultimately
+// reported is selected by severity once every condition has been
+// evaluated.
+func (icc *issueChangeConfig) enterState(state milestoneState) {
+	icc.state = state
+	icc.states = append(icc.states, state)
+}
+
+func (icc *issueChangeConfig) summarizeLabels(objType, kindLabel, priorityLabel string, sigLabels []string, linkedPRs []int) {
+	icc.enableSection("summarizeLabels")
+	icc.enterState(milestoneCurrent)
+	quotedSigLabels := []string{}
+	for _, sigLabel := range sigLabels {
+		quotedSigLabels = append(quotedSigLabels, quoteLabel(sigLabel))
+	}
+	arguments := map[string]interface{}{
+		"kindLabel":           quoteLabel(kindLabel),
+		"kindDescription":     kindMap[kindLabel],
+		"priorityLabel":       quoteLabel(priorityLabel),
+		"priorityDescription": fmt.Sprintf(priorityMap[priorityLabel], objType),
+		"sigLabels":           quotedSigLabels,
+		"linkedPRs":           linkedPRs,
+	}
+	for k, v := range arguments {
+		icc.templateArguments[k] = v
+	}
+	icc.sigLabels = sigLabels
+}
+
+func (icc *issueChangeConfig) warnUnapproved(removeAfter *time.Duration, objType, milestone string) {
+	icc.enableSection("warnUnapproved")
+	icc.removeAfter = removeAfter
+	icc.enterState(milestoneNeedsApproval)
+	var warning string
+	if removeAfter != nil {
+		warning = fmt.Sprintf(" If the label is not applied within %s, the %s will be moved out of the %s.",
+			fmt.Sprintf(milestoneCountdownTemplate, durationToDaysAndHours(*removeAfter)), objType, milestone)
+	}
+	icc.templateArguments["unapprovedRemovalWarning"] = warning
+
+	// Name the approvers from the OWNERS hierarchy when they are
+	// known, falling back to reminding the owner which SIGs are
+	// expected to approve.
+	reminder := ""
+	if len(icc.eligibleApprovers) > 0 {
+		mentions := []string{}
+		for _, approver := range icc.eligibleApprovers {
+			mentions = append(mentions, fmt.Sprintf("@%s", approver))
+		}
+		reminder = fmt.Sprintf(" The following approvers can apply the label: %s.", strings.Join(mentions, ", "))
+	} else if len(icc.sigLabels) > 0 {
+		quotedSigLabels := []string{}
+		for _, sigLabel := range icc.sigLabels {
+			quotedSigLabels = append(quotedSigLabels, quoteLabel(sigLabel))
+		}
+		reminder = fmt.Sprintf(" Approval is needed from %s", strings.Join(quotedSigLabels, ", "))
+		if mentions := icc.sigMentions(); len(mentions) > 0 {
+			reminder = fmt.Sprintf("%s (%s)", reminder, mentions)
+		}
+		reminder += "."
+	}
+	icc.templateArguments["unapprovedSigReminder"] = reminder
+}
+
+func (icc *issueChangeConfig) removeByRequest(requester string) {
+	icc.enableSection("removeByRequest")
+	icc.enterState(milestoneNeedsRemoval)
+	icc.templateArguments["removalRequester"] = fmt.Sprintf("@%s", requester)
+	icc.removalReason = milestoneRemovalReasonByRequest
+}
+
+func (icc *issueChangeConfig) removeUnapproved() {
+	icc.enableSection("removeUnapproved")
+	icc.enterState(milestoneNeedsRemoval)
+	icc.removalReason = milestoneRemovalReasonUnapproved
+}
+
+func (icc *issueChangeConfig) warnMissingInProgress() {
+	icc.enableSection("warnMissingInProgress")
+	icc.enterState(milestoneNeedsAttention)
+}
+
+func (icc *issueChangeConfig) warnMissingAssignee() {
+	icc.enableSection("warnMissingAssignee")
+	icc.enterState(milestoneNeedsAttention)
+}
+
+func (icc *issueChangeConfig) warnMissingDesignDoc() {
+	icc.enableSection("warnMissingDesignDoc")
+	icc.enterState(milestoneNeedsAttention)
+}
+
+func (icc *issueChangeConfig) warnMissingETA() {
+	icc.enableSection("warnMissingETA")
+	icc.enterState(milestoneNeedsAttention)
+}
+
+func (icc *issueChangeConfig) warnUpdateRequired(lastUpdated time.Time, lastUpdatedURL string) {
+	icc.enableSection("warnUpdateRequired")
+	icc.enterState(milestoneNeedsAttention)
+	icc.templateArguments["lastUpdated"] = formatLastUpdated(lastUpdated, lastUpdatedURL)
+}
+
+func (icc *issueChangeConfig) warnUpdateAtRisk(lastUpdated time.Time, lastUpdatedURL string) {
+	icc.enableSection("warnUpdateAtRisk")
+	icc.enterState(milestoneAtRisk)
+	icc.templateArguments["lastUpdated"] = formatLastUpdated(lastUpdated, lastUpdatedURL)
+}
+
+// formatLastUpdated renders the date of the last update, linked to the
+// update itself when its url is known.
+func formatLastUpdated(lastUpdated time.Time, lastUpdatedURL string) string {
+	formatted := lastUpdated.Format("Jan 2")
+	if len(lastUpdatedURL) > 0 {
+		return fmt.Sprintf("[%s](%s)", formatted, lastUpdatedURL)
+	}
+	return formatted
+}
+
+func (icc *issueChangeConfig) warnUpdateInterval() {
+	icc.enableSection("warnUpdateInterval")
+}
+
+func (icc *issueChangeConfig) warnNonBlockerRemoval() {
+	icc.enableSection("warnNonBlockerRemoval")
+	icc.enterState(milestoneNeedsAttention)
+}
+
+func (icc *issueChangeConfig) removeNonBlocker() {
+	icc.enableSection("removeNonBlocker")
+	icc.enterState(milestoneNeedsRemoval)
+	icc.removalReason = milestoneRemovalReasonNonBlocker
+}
+
+func (icc *issueChangeConfig) warnNeedsTriage(labelErrors []string) {
+	icc.enableSection("warnNeedsTriage")
+	icc.enterState(milestoneNeedsTriage)
+	icc.templateArguments["labelErrors"] = labelErrors
+}
+
+func (icc *issueChangeConfig) warnIncompleteLabels(removeAfter *time.Duration, labelErrors []string, objType, milestone string) {
+	icc.enableSection("warnIncompleteLabels")
+	icc.removeAfter = removeAfter
+	icc.enterState(milestoneNeedsLabeling)
+	var warning string
+	if removeAfter != nil {
+		warning = fmt.Sprintf(" If the labels are not applied within %s, the %s will be moved out of the %s.",
+			fmt.Sprintf(milestoneCountdownTemplate, durationToDaysAndHours(*removeAfter)), objType, milestone)
+	}
+	icc.templateArguments["incompleteLabelsRemovalWarning"] = warning
+	icc.templateArguments["labelErrors"] = labelErrors
+}
+
+func (icc *issueChangeConfig) removeIncompleteLabels(labelErrors []string) {
+	icc.enableSection("removeIncompleteLabels")
+	icc.enterState(milestoneNeedsRemoval)
+	icc.templateArguments["labelErrors"] = labelErrors
+	icc.removalReason = milestoneRemovalReasonIncompleteLabels
+}
+
+func (icc *issueChangeConfig) sigMentions() string {
+	return sigMentions(icc.sigLabels, icc.sigMentionTemplate, icc.sigMentionOverrides)
+}
+
+// sigMentions renders the mention handles for the given sig labels
+// using the configured mention template, with per-sig overrides taking
+// precedence (e.g. an umbrella sig whose team name does not follow the
+// template's suffix). Distinct sig labels can expand to the same
+// handle, so duplicate targets are collapsed in order of first
+// appearance.
+func sigMentions(sigLabels []string, mentionTemplate string, overrides map[string]string) string {
+	mentions := []string{}
+	seen := sets.NewString()
+	for _, label := range sigLabels {
+		sig := strings.TrimPrefix(label, sigLabelPrefix)
+		target := fmt.Sprintf(mentionTemplate, sig)
+		if override, ok := overrides[label]; ok {
+			target = override
+		}
+		if seen.Has(target) {
+			continue
+		}
+		seen.Insert(target)
+		mentions = append(mentions, target)
+	}
+	return strings.Join(mentions, " ")
+}
+
+// handleApprovalRequests responds to an approval request command on an
+// unapproved issue by pinging the sig-leads derived from the issue's
+// sig labels. Responses are rate-limited to the warning interval so
+// that repeated commands do not spam the sigs.
+func (m *MilestoneMaintainer) handleApprovalRequests(obj *github.MungeObject) {
+	if obj.HasLabel(statusApprovedLabel) {
+		return
+	}
+	issueComments, ok := obj.ListComments()
+	if !ok {
+		return
+	}
+	comments := c.FromIssueComments(issueComments)
+	command := c.FilterComments(comments, c.Command(milestoneRequestApprovalCommand)).GetLast()
+	if command == nil {
+		return
+	}
+	response := c.FilterComments(comments, c.MungerNotificationNames(milestoneApprovalRequestNotifierName, m.botNames())).GetLast()
+	if response != nil {
+		if response.CreatedAt != nil && command.CreatedAt != nil && !command.CreatedAt.After(*response.CreatedAt) {
+			// The latest command has already been answered.
+			return
+		}
+		if response.CreatedAt != nil && m.now().Sub(*response.CreatedAt) < m.warningInterval {
+			return
+		}
+	}
+	mentions := sigMentions(m.effectiveSigLabels(obj.Issue.Labels), m.sigMentionTemplate, m.sigMentionOverrides)
+	if len(mentions) == 0 {
+		return
+	}
+	requester := ""
+	if command.Author != nil {
+		requester = fmt.Sprintf("@%s ", *command.Author)
+	}
+	message := fmt.Sprintf("%s\n\n%shas requested approval of this issue for the %s milestone. If you agree, please apply the %s label.",
+		mentions, requester, m.activeMilestoneFor(obj), quoteLabel(statusApprovedLabel))
+	notification := c.NewNotification(milestoneApprovalRequestNotifierName, "", message)
+	if err := notification.Post(obj); err != nil {
+		glog.Errorf("Failed to respond to %q on %d: %v", milestoneRequestApprovalCommand, obj.Number(), err)
+	}
+}
+
+// handleStatusQueries replies to a status command with the issue's
+// computed state, enabled conditions and remaining grace time, without
+// changing any labels. Responses are rate-limited to the warning
+// interval so that repeated commands do not spam the issue.
+func (m *MilestoneMaintainer) handleStatusQueries(obj *github.MungeObject) {
+	issueComments, ok := obj.ListComments()
+	if !ok {
+		return
+	}
+	comments := c.FromIssueComments(issueComments)
+	command := c.FilterComments(comments, c.Command(milestoneStatusCommand)).GetLast()
+	if command == nil {
+		return
+	}
+	response := c.FilterComments(comments, c.MungerNotificationNames(milestoneStatusQueryNotifierName, m.botNames())).GetLast()
+	if response != nil {
+		if response.CreatedAt != nil && command.CreatedAt != nil && !command.CreatedAt.After(*response.CreatedAt) {
+			// The latest command has already been answered.
+			return
+		}
+		if response.CreatedAt != nil && m.now().Sub(*response.CreatedAt) < m.warningInterval {
+			return
+		}
+	}
+	icc := m.issueChangeConfig(obj)
+	if icc == nil {
+		return
+	}
+	requester := ""
+	if command.Author != nil {
+		requester = fmt.Sprintf("@%s ", *command.Author)
+	}
+	lines := []string{
+		fmt.Sprintf("%sThis issue is currently in state `%s` (mode: `%s`).", requester, icc.state, m.effectiveMode(m.now())),
+		fmt.Sprintf("Conditions: %s", strings.Join(icc.enabledSections.List(), ", ")),
+	}
+	if icc.removeAfter != nil {
+		lines = append(lines, fmt.Sprintf("Remaining grace time: %s.", durationToDaysAndHours(*icc.removeAfter)))
+	}
+	notification := c.NewNotification(milestoneStatusQueryNotifierName, "", strings.Join(lines, "\n"))
+	if err := notification.Post(obj); err != nil {
+		glog.Errorf("Failed to respond to %q on %d: %v", milestoneStatusCommand, obj.Number(), err)
+	}
+}
+
+// applyStatusCommands applies the status labels requested on the issue
+// via comment commands from authorized users. A user is authorized if
+// they are a member of one of the sigs the issue is labeled for.
+func (m *MilestoneMaintainer) applyStatusCommands(obj *github.MungeObject) {
+	if m.hasInProgressLabel(obj) {
+		return
+	}
+	issueComments, ok := obj.ListComments()
+	if !ok {
+		return
+	}
+	commands := c.FilterComments(c.FromIssueComments(issueComments), c.Command(milestoneInProgressCommand))
+	sigs := m.effectiveSigLabels(obj.Issue.Labels)
+	for _, comment := range commands {
+		if comment.Author == nil {
+			continue
+		}
+		if !m.authorizedForSigs(*comment.Author, sigs) {
+			glog.Infof("Ignoring %q from %s on %d: not a member of %v", milestoneInProgressCommand, *comment.Author, obj.Number(), sigs)
+			continue
+		}
+		if err := obj.AddLabel(statusInProgressLabel); err != nil {
+			glog.Errorf("Failed to apply %q to %d: %v", statusInProgressLabel, obj.Number(), err)
+		}
+		return
+	}
+}
+
+// applyApprovalCommands applies the approval label requested on the
+// issue via comment commands. Approval is only accepted from members
+// of one of the sigs the issue is labeled for.
+func (m *MilestoneMaintainer) applyApprovalCommands(obj *github.MungeObject) {
+	if obj.HasLabel(statusApprovedLabel) {
+		return
+	}
+	issueComments, ok := obj.ListComments()
+	if !ok {
+		return
+	}
+	commands := c.FilterComments(c.FromIssueComments(issueComments), c.Command(milestoneApproveCommand))
+	sigs := m.effectiveSigLabels(obj.Issue.Labels)
+	for _, comment := range commands {
+		if comment.Author == nil {
+			continue
+		}
+		if !m.authorizedForSigs(*comment.Author, sigs) {
+			glog.Infof("Ignoring %q from %s on %d: not a member of %v", milestoneApproveCommand, *comment.Author, obj.Number(), sigs)
+			continue
+		}
+		if err := obj.AddLabel(statusApprovedLabel); err != nil {
+			glog.Errorf("Failed to apply %q to %d: %v", statusApprovedLabel, obj.Number(), err)
+		}
+		return
+	}
+}
+
+// applyPriorityCommands applies priority changes requested on the
+// issue via /priority commands from authorized users. Running before
+// the issue is evaluated means a promotion to blocker is reflected in
+// the same munge rather than on the next loop.
+func (m *MilestoneMaintainer) applyPriorityCommands(obj *github.MungeObject) {
+	issueComments, ok := obj.ListComments()
+	if !ok {
+		return
+	}
+	sigs := m.effectiveSigLabels(obj.Issue.Labels)
+	requested := ""
+	for _, comment := range c.FromIssueComments(issueComments) {
+		if comment.Body == nil || comment.Author == nil {
+			continue
+		}
+		firstLine := strings.TrimSpace(strings.SplitN(strings.TrimSpace(*comment.Body), "\n", 2)[0])
+		if !strings.HasPrefix(firstLine, milestonePriorityCommand+" ") {
+			continue
+		}
+		priorityLabel := priorityLabelPrefix + strings.TrimSpace(strings.TrimPrefix(firstLine, milestonePriorityCommand+" "))
+		if _, known := priorityMap[priorityLabel]; !known {
+			continue
+		}
+		if !m.authorizedForSigs(*comment.Author, sigs) {
+			glog.Infof("Ignoring %q from %s on %d: not a member of %v", firstLine, *comment.Author, obj.Number(), sigs)
+			continue
+		}
+		// The last authorized command wins.
+		requested = priorityLabel
+	}
+	if requested == "" || obj.HasLabel(requested) {
+		return
+	}
+	for priorityLabel := range priorityMap {
+		if priorityLabel != requested && obj.HasLabel(priorityLabel) {
+			if err := obj.RemoveLabel(priorityLabel); err != nil {
+				glog.Errorf("Failed to remove %q from %d: %v", priorityLabel, obj.Number(), err)
+				return
+			}
+		}
+	}
+	if err := obj.AddLabel(requested); err != nil {
+		glog.Errorf("Failed to apply %q to %d: %v", requested, obj.Number(), err)
+	}
+}
+
+// applyDefaultPriority applies the configured default priority label
+// to an issue lacking any priority, noting the application in a
+// comment, instead of warning indefinitely about the missing label.
+func (m *MilestoneMaintainer) applyDefaultPriority(obj *github.MungeObject) {
+	if m.defaultPriority == "" {
+		return
+	}
+	// Re-validate here: the options framework applies raw values before
+	// the callbacks run and only logs their errors, so an invalid
+	// default could still be in effect.
+	if _, known := priorityMap[m.defaultPriority]; !known {
+		glog.Errorf("Ignoring invalid %s %q", milestoneOptDefaultPriority, m.defaultPriority)
+		return
+	}
+	for priorityLabel := range priorityMap {
+		if obj.HasLabel(priorityLabel) {
+			return
+		}
+	}
+	if err := obj.AddLabel(m.defaultPriority); err != nil {
+		glog.Errorf("Failed to apply %q to %d: %v", m.defaultPriority, obj.Number(), err)
+		return
+	}
+	if m.labelsOnly {
+		return
+	}
+	if err := obj.WriteComment(fmt.Sprintf(milestoneDefaultPriorityComment, m.defaultPriority)); err != nil {
+		glog.Errorf("Failed to comment on %d: %v", obj.Number(), err)
+	}
+}
+
+// approvedViaReview indicates whether a linked open pull request
+// carries an approving review from a member of one of the issue's
+// sigs, which some sigs treat as implying milestone approval.
+func (m *MilestoneMaintainer) approvedViaReview(obj *github.MungeObject) (bool, bool) {
+	prs, ok := obj.OpenLinkedPRs()
+	if !ok {
+		return false, false
+	}
+	sigs := m.effectiveSigLabels(obj.Issue.Labels)
+	for _, number := range prs {
+		reviews, ok := obj.PRReviews(number)
+		if !ok {
+			return false, false
+		}
+		for _, review := range reviews {
+			if review.State == nil || *review.State != "APPROVED" || review.User == nil || review.User.Login == nil {
+				continue
+			}
+			if m.authorizedForSigs(*review.User.Login, sigs) {
+				return true, true
+			}
+		}
+	}
+	return false, true
+}
+
+// authorizedForSigs indicates whether the given login is a member of
+// any of the sigs indicated by the given sig labels.
+func (m *MilestoneMaintainer) authorizedForSigs(login string, sigLabels []string) bool {
+	if m.features == nil || m.features.SigMembership == nil {
+		return false
+	}
+	for _, label := range sigLabels {
+		if m.features.SigMembership.IsMember(strings.TrimPrefix(label, sigLabelPrefix), login) {
+			return true
+		}
+	}
+	return false
+}
+
+// removalExempt indicates whether the issue carries a priority label
+// or sits in a milestone (e.g. a patch milestone) configured as exempt
+// from removal. Exempt issues are treated like blockers for removal
+// purposes: they continue to receive warnings but are never moved out
+// of the milestone.
+func (m *MilestoneMaintainer) removalExempt(obj *github.MungeObject) bool {
+	for _, priority := range m.removalExemptPriorities {
+		if obj.HasLabel(priority) {
+			return true
+		}
+	}
+	if milestone, ok := obj.ReleaseMilestone(); ok {
+		for _, exempt := range m.removalExemptMilestones {
+			if milestone == exempt {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// restoreRemoved returns an issue to the active milestone when a
+// maintainer countermands an automated removal with the restore
+// command. The command is only honored within the restore window
+// following a removal performed by the munger itself, and only from a
+// member of one of the issue's sigs. Returns true when the issue was
+// restored.
+func (m *MilestoneMaintainer) restoreRemoved(obj *github.MungeObject, activeMilestone string) bool {
+	if m.restoreWindow <= 0 || !obj.HasLabel(milestoneRemovedLabel) {
+		return false
+	}
+	removedAt, ok := m.removalTime(obj)
+	if !ok || m.now().Sub(*removedAt) > m.restoreWindow {
+		return false
+	}
+	issueComments, ok := obj.ListComments()
+	if !ok {
+		return false
+	}
+	commands := c.FilterComments(c.FromIssueComments(issueComments), c.Command(milestoneRestoreCommand))
+	sigs := m.effectiveSigLabels(obj.Issue.Labels)
+	for _, comment := range commands {
+		if comment.Author == nil || comment.CreatedAt == nil || comment.CreatedAt.Before(*removedAt) {
+			continue
+		}
+		if !m.authorizedForSigs(*comment.Author, sigs) {
+			glog.Infof("Ignoring %q from %s on %d: not a member of %v", milestoneRestoreCommand, *comment.Author, obj.Number(), sigs)
+			continue
+		}
+		if err := obj.SetMilestone(activeMilestone); err != nil {
+			return false
+		}
+		if err := obj.RemoveLabel(milestoneRemovedLabel); err != nil {
+			glog.Errorf("Failed to remove %q from %d: %v", milestoneRemovedLabel, obj.Number(), err)
+		}
+		return true
+	}
+	return false
+}
+
+// removalRequested returns the author of an authorized removal
+// command on the issue. Only commands posted after the most recent
+// automated removal are honored, so that a restored issue is not
+// immediately re-removed by a stale command.
+func (m *MilestoneMaintainer) removalRequested(obj *github.MungeObject) (string, bool) {
+	issueComments, ok := obj.ListComments()
+	if !ok {
+		return "", false
+	}
+	var removedAt *time.Time
+	if t, ok := m.removalTime(obj); ok {
+		removedAt = t
+	}
+	commands := c.FilterComments(c.FromIssueComments(issueComments), c.Command(milestoneRemoveCommand))
+	sigs := m.effectiveSigLabels(obj.Issue.Labels)
+	for _, comment := range commands {
+		if comment.Author == nil || comment.CreatedAt == nil {
+			continue
+		}
+		if removedAt != nil && !comment.CreatedAt.After(*removedAt) {
+			continue
+		}
+		if !m.authorizedForSigs(*comment.Author, sigs) {
+			glog.Infof("Ignoring %q from %s on %d: not a member of %v", milestoneRemoveCommand, *comment.Author, obj.Number(), sigs)
+			continue
+		}
+		return *comment.Author, true
+	}
+	return "", false
+}
+
+// removalTime returns the time at which the munger removed the issue
+// from the milestone, according to its label events. A removal label
+// applied by anyone else is not restorable.
+func (m *MilestoneMaintainer) removalTime(obj *github.MungeObject) (*time.Time, bool) {
+	events, ok := obj.GetEvents()
+	if !ok {
+		return nil, false
+	}
+	matcher := event.And([]event.Matcher{
+		event.AddLabel{},
+		event.LabelName(milestoneRemovedLabel),
+		event.Actors(m.botNames()),
+	})
+	removal := event.FilterEvents(events, matcher).GetLast()
+	if removal == nil || removal.CreatedAt == nil {
+		return nil, false
+	}
+	return removal.CreatedAt, true
+}
+
+// resolvedWarningState indicates whether the issue is currently in a
+// warning state that the munger applied, i.e. whether a transition to
+// milestoneCurrent resolves a previous warning.
+func (m *MilestoneMaintainer) resolvedWarningState(obj *github.MungeObject) bool {
+	prior, ok := lastAppliedStateLabel(obj, m.botNames())
+	if !ok || prior == "" || prior == milestoneRemovedLabel {
+		return false
+	}
+	return obj.HasLabel(prior)
+}
+
+// lastAppliedStateLabel returns the milestone state label most
+// recently applied by the munger, according to the issue's label
+// events.
+func lastAppliedStateLabel(obj *github.MungeObject, botNames []string) (string, bool) {
+	events, ok := obj.GetEvents()
+	if !ok {
+		return "", false
+	}
+	labelMatcher := event.And([]event.Matcher{
+		event.AddLabel{},
+		event.Actors(botNames),
+	})
+	last := ""
+	for _, labelEvent := range event.FilterEvents(events, labelMatcher) {
+		if labelEvent.Label == nil || labelEvent.Label.Name == nil {
+			continue
+		}
+		for _, stateLabel := range milestoneStateLabels {
+			if *labelEvent.Label.Name == stateLabel {
+				last = stateLabel
+			}
+		}
+	}
+	return last, true
+}
+
+// resolvedNotification returns the one-time notification acknowledging
+// that an issue has satisfied the milestone process requirements.
+func (m *MilestoneMaintainer) resolvedNotification(obj *github.MungeObject) *c.Notification {
+	mentions := mungerutil.GetIssueUsers(obj.Issue).AllUsers().Mention().Join()
+	message := fmt.Sprintf("%s\n\nThis issue now satisfies the milestone process requirements. No further action is required.\n%s", mentions, m.detailSection(m.effectiveSigLabels(obj.Issue.Labels)))
+	return c.NewNotification(milestoneNotifierName, milestoneResolvedTitle, message)
+}
+
+// readdRemovedBlocker returns an issue that was previously removed
+// from the active milestone - and has since regained blocker status -
+// to the milestone.
+func (m *MilestoneMaintainer) readdRemovedBlocker(obj *github.MungeObject) {
+	if err := obj.SetMilestone(m.activeMilestoneFor(obj)); err != nil {
+		return
+	}
+	if err := obj.RemoveLabel(milestoneRemovedLabel); err != nil {
+		return
+	}
+}
+
+// milestoneTitleNearMiss indicates whether the given milestone title
+// looks like a typo of the active milestone: it matches the configured
+// title pattern without being the active milestone itself.
+func milestoneTitleNearMiss(title, activeMilestone string, titlePattern *regexp.Regexp) bool {
+	if titlePattern == nil || title == activeMilestone {
+		return false
+	}
+	return titlePattern.MatchString(title)
+}
+
+// loadTemplateOverrides loads the message and detail template override
+// files, falling back to the built-in templates when no path is
+// configured. The message template is test-rendered so that a broken
+// override fails at load time rather than during a munge.
+func (m *MilestoneMaintainer) loadTemplateOverrides() error {
+	m.messageTemplateOverride = ""
+	if m.messageTemplateFile != "" {
+		contents, err := ioutil.ReadFile(m.messageTemplateFile)
+		if err != nil {
+			return fmt.Errorf("unable to read %q: %v", milestoneOptMessageTemplateFile, err)
+		}
+		if err := validateMessageTemplate(string(contents)); err != nil {
+			return fmt.Errorf("invalid %q: %v", milestoneOptMessageTemplateFile, err)
+		}
+		m.messageTemplateOverride = string(contents)
+	}
+	m.detailTemplateOverride = ""
+	if m.detailTemplateFile != "" {
+		contents, err := ioutil.ReadFile(m.detailTemplateFile)
+		if err != nil {
+			return fmt.Errorf("unable to read %q: %v", milestoneOptDetailTemplateFile, err)
+		}
+		m.detailTemplateOverride = string(contents)
+	}
+	return nil
+}
+
+// validateMessageTemplate test-renders the given message template with
+// dummy arguments to catch broken overrides.
+func validateMessageTemplate(template string) error {
+	arguments := map[string]interface{}{
+		"warnUnapproved":       true,
+		"warnIncompleteLabels": true,
+		"labelErrors":          []string{},
+		"sigLabels":            []string{},
+	}
+	if rendered := approvers.GenerateTemplateOrFail(template, "message", arguments); rendered == nil {
+		return fmt.Errorf("message template failed to render")
+	}
+	return nil
+}
+
+// parseRepoMilestones parses per-repository active milestone overrides
+// from a spec of the form "org1/repo1=v1.10,org2/repo2=v2.3".
+func parseRepoMilestones(spec string) (map[string]string, error) {
+	repoMilestones := map[string]string{}
+	for _, pairSpec := range strings.Split(spec, ",") {
+		pairSpec = strings.TrimSpace(pairSpec)
+		if pairSpec == "" {
+			continue
+		}
+		fields := strings.SplitN(pairSpec, "=", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid repo milestone %q: expected org/repo=milestone", pairSpec)
+		}
+		repo := strings.TrimSpace(fields[0])
+		milestone := strings.TrimSpace(fields[1])
+		if !strings.Contains(repo, "/") || milestone == "" {
+			return nil, fmt.Errorf("invalid repo milestone %q: expected org/repo=milestone", pairSpec)
+		}
+		repoMilestones[repo] = milestone
+	}
+	return repoMilestones, nil
+}
+
+// parseHolidays parses a comma-separated list of dates (e.g.
+// '2018-12-25,2019-01-01') into the set of days excluded from
+// business-time grace period accounting.
+func parseHolidays(spec string) (map[string]bool, error) {
+	holidays := map[string]bool{}
+	for _, dateSpec := range strings.Split(spec, ",") {
+		dateSpec = strings.TrimSpace(dateSpec)
+		if dateSpec == "" {
+			continue
+		}
+		date, err := time.Parse(milestoneBlackoutDateFormat, dateSpec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday %q: %v", dateSpec, err)
+		}
+		holidays[date.Format(milestoneBlackoutDateFormat)] = true
+	}
+	return holidays, nil
+}
+
+// activeMilestoneFor returns the active milestone for the repository
+// the given object belongs to, falling back to the globally configured
+// milestone for repos without an override.
+func (m *MilestoneMaintainer) activeMilestoneFor(obj *github.MungeObject) string {
+	if milestone, ok := m.repoMilestones[obj.Repo()]; ok {
+		return milestone
+	}
+	return m.activeMilestone
+}
+
+// botNames returns the bot logins whose comments and label events the
+// munger recognizes as its own: the current login plus any legacy
+// logins configured for a bot migration.
+func (m *MilestoneMaintainer) botNames() []string {
+	return append([]string{m.botName}, m.legacyBotNames...)
+}
+
+// isBotName indicates whether the given login is one of the recognized
+// bot logins.
+func isBotName(login string, botNames []string) bool {
+	for _, botName := range botNames {
+		if strings.EqualFold(login, botName) {
+			return true
+		}
+	}
+	return false
+}
+
+// issueClosed indicates whether the given issue is closed.
+func issueClosed(obj *github.MungeObject) bool {
+	return obj.Issue.State != nil && *obj.Issue.State == "closed"
+}
+
+// milestoneCacheStale indicates whether the cached milestone of the
+// given issue disagrees with its most recent milestone-change event,
+// suggesting a change the issue cache has not yet caught up with.
+// Acting on stale data risks warning or removing the wrong issue, so
+// a stale issue is skipped until the next sync.
+func milestoneCacheStale(obj *github.MungeObject) bool {
+	events, ok := obj.GetEvents()
+	if !ok {
+		return false
+	}
+	var latest *githubapi.IssueEvent
+	for _, event := range events {
+		if event.Event == nil || (*event.Event != "milestoned" && *event.Event != "demilestoned") {
+			continue
+		}
+		if latest == nil || (event.CreatedAt != nil && latest.CreatedAt != nil && event.CreatedAt.After(*latest.CreatedAt)) {
+			latest = event
+		}
+	}
+	if latest == nil {
+		return false
+	}
+	eventMilestone := ""
+	if *latest.Event == "milestoned" && latest.Milestone != nil && latest.Milestone.Title != nil {
+		eventMilestone = *latest.Milestone.Title
+	}
+	cached, ok := obj.ReleaseMilestone()
+	if !ok {
+		return false
+	}
+	if cached != eventMilestone {
+		glog.Infof("Skipping %d for this pass: cached milestone %q disagrees with the most recent milestone event %q", *obj.Issue.Number, cached, eventMilestone)
+		return true
+	}
+	return false
+}
+
+// ignoreObject indicates whether the munger should ignore the given
+// object.
+func ignoreObject(obj *github.MungeObject, activeMilestone string, titlePattern *regexp.Regexp, readdBlockers bool, blockerLabelName string, cleanupClosed bool, exemptAuthors []string, ignoreTitlePrefixes []string) bool {
+	// Only munge issues
+	if obj.IsPR() {
+		return true
+	}
+
+	// Placeholder issues are intentionally incomplete trackers; a
+	// configured title marker keeps them from being nagged or removed.
+	if obj.Issue.Title != nil {
+		for _, prefix := range ignoreTitlePrefixes {
+			if strings.HasPrefix(strings.TrimSpace(*obj.Issue.Title), prefix) {
+				return true
+			}
+		}
+	}
+
+	// Only munge open issues, unless closed issues are eligible for
+	// state label cleanup.
+	if issueClosed(obj) && !cleanupClosed {
+		return true
+	}
+
+	// Locked issues cannot be commented on, so skip them entirely
+	// rather than failing partway through a munge.
+	if obj.Issue.Locked != nil && *obj.Issue.Locked {
+		glog.Infof("Skipping locked issue %d", obj.Number())
+		return true
+	}
+
+	// Issues opened by exempt authors (e.g. dependency bots) are not
+	// subject to the milestone process.
+	if obj.Issue.User != nil && obj.Issue.User.Login != nil && isBotName(*obj.Issue.User.Login, exemptAuthors) {
+		return true
+	}
+
+	// Guard against a milestone reference with no usable title, which
+	// can occur when a milestone is deleted on github.
+	if obj.Issue.Milestone != nil && (obj.Issue.Milestone.Title == nil || *obj.Issue.Milestone.Title == "") {
+		return true
+	}
+
+	// Only munge issues in the active milestone, with the exception
+	// of issues removed from the milestone that have since been
+	// labeled as blockers.
+	milestone, ok := obj.ReleaseMilestone()
+	if !ok {
+		return true
+	}
+	if milestone != activeMilestone {
+		if milestoneTitleNearMiss(milestone, activeMilestone, titlePattern) {
+			// Purely diagnostic; the issue is still ignored.
+			glog.Warningf("Issue %d has milestone %q which matches %q but is not the active milestone %q; possible typo?",
+				obj.Number(), milestone, titlePattern, activeMilestone)
+		}
+		if readdBlockers && obj.HasLabel(milestoneRemovedLabel) && obj.HasLabel(blockerLabelName) {
+			return false
+		}
+		return true
+	}
+
+	return false
+}
+
+// latestNotificationComment returns the most recent notification
+// comment posted by the munger.
+//
+// Since the munger is careful to remove existing comments before
+// adding new ones, only a single notification comment should exist.
+func latestNotificationComment(obj *github.MungeObject, botNames []string) (*c.Comment, bool) {
+	issueComments, ok := obj.ListComments()
+	if !ok {
+		return nil, false
+	}
+	comments := c.FromIssueComments(issueComments)
+	notificationMatcher := c.MungerNotificationNames(milestoneNotifierName, botNames)
+	notifications := c.FilterComments(comments, notificationMatcher)
+	return notifications.GetLast(), true
+}
+
+// firstContactIsCurrent indicates whether the most recent notification
+// is a first-contact heads-up younger than the warning interval.
+// Escalating the wording before the interval has elapsed would change
+// the body and force a repost on the loop immediately after the
+// heads-up was posted.
+func (m *MilestoneMaintainer) firstContactIsCurrent(obj *github.MungeObject, now time.Time) bool {
+	comment, ok := latestNotificationComment(obj, m.botNames())
+	if !ok || comment == nil || comment.CreatedAt == nil {
+		return false
+	}
+	notification := c.ParseNotification(comment)
+	if notification == nil || !strings.Contains(notification.Context, "**Heads-up**:") {
+		return false
+	}
+	return now.Sub(*comment.CreatedAt) < m.warningInterval
+}
+
+// notificationIsCurrent indicates whether the given notification
+// matches the most recent notification comment and the comment
+// interval - if provided - has not been exceeded as of the given time.
+func notificationIsCurrent(notification *c.Notification, comment *c.Comment, commentInterval *time.Duration, now time.Time) bool {
+	return notificationsMatch(notification, comment) && (commentInterval == nil ||
+		(comment != nil && comment.CreatedAt != nil && now.Sub(*comment.CreatedAt) < *commentInterval))
+}
+
+// notificationsMatch indicates whether the given notification is
+// equivalent to the notification in the given comment, ignoring the
+// warning repeat marker.
+func notificationsMatch(notification *c.Notification, comment *c.Comment) bool {
+	oldNotification := stripRepeatMarker(c.ParseNotification(comment))
+	return oldNotification != nil && oldNotification.Equal(stripRepeatMarker(notification))
+}
+
+// milestoneWarningRepeatMarker records - in an html comment invisible
+// to readers - how many times a warning notification has been
+// reposted due to its interval expiring.
+const milestoneWarningRepeatMarker = "<!-- warning-repeat: %d -->"
+
+var milestoneWarningRepeatRE = regexp.MustCompile(`<!-- warning-repeat: ([0-9]+) -->`)
+
+// milestoneWarningCountTemplate renders the reminder ordinal included
+// in warning sections. The markers delimit the text so that it can be
+// excluded from notification equality; otherwise an incremented count
+// would make every notification look new.
+const milestoneWarningCountTemplate = " <!-- warning-count -->This is reminder #%d.<!-- /warning-count -->"
+
+// milestoneCountdownTemplate renders the remaining grace time inside
+// markers so that the hour-granular countdown can be excluded from
+// notification equality; otherwise the text would change every hour
+// and force a repost. The countdown refreshes only when the warning
+// interval expires and the notification is reposted.
+const milestoneCountdownTemplate = "<!-- countdown -->%s<!-- /countdown -->"
+
+var (
+	milestoneWarningCountRE  = regexp.MustCompile(` ?<!-- warning-count -->.*?<!-- /warning-count -->`)
+	milestoneCountdownRE     = regexp.MustCompile(`<!-- countdown -->.*?<!-- /countdown -->`)
+	milestoneWarningNumberRE = regexp.MustCompile(`This is reminder #([0-9]+)\.`)
+
+	milestoneNotificationKeyRE = regexp.MustCompile(`\n?<!-- milestone-notification-key: [0-9a-f]+ -->`)
+)
+
+// warningCount returns the number of warning notifications the munger
+// has already posted on the issue. Since reposting replaces the
+// previous comment, the count is carried forward by the ordinal
+// rendered in the latest notification (and the repeat marker, when
+// backoff is enabled).
+func warningCount(obj *github.MungeObject, botNames []string) (int, bool) {
+	issueComments, ok := obj.ListComments()
+	if !ok {
+		return 0, false
+	}
+	comments := c.FromIssueComments(issueComments)
+	notifications := c.FilterComments(comments, c.MungerNotificationNames(milestoneNotifierName, botNames))
+	count := len(notifications)
+	if last := notifications.GetLast(); last != nil {
+		count += notificationRepeatCount(last)
+		if ordinal := notificationWarningNumber(last); ordinal > count {
+			count = ordinal
+		}
+	}
+	return count, true
+}
+
+// notificationWarningNumber returns the reminder ordinal rendered in
+// the given notification comment, or zero if it carries none.
+func notificationWarningNumber(comment *c.Comment) int {
+	notification := c.ParseNotification(comment)
+	if notification == nil {
+		return 0
+	}
+	match := milestoneWarningNumberRE.FindStringSubmatch(notification.Context)
+	if match == nil {
+		return 0
+	}
+	number, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0
+	}
+	return number
+}
+
+// notificationRepeatCount returns the number of times the notification
+// in the given comment has been reposted. Notifications posted before
+// backoff was enabled carry no marker and count as zero.
+func notificationRepeatCount(comment *c.Comment) int {
+	notification := c.ParseNotification(comment)
+	if notification == nil {
+		return 0
+	}
+	match := milestoneWarningRepeatRE.FindStringSubmatch(notification.Context)
+	if match == nil {
+		return 0
+	}
+	count, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// stripRepeatMarker returns a copy of the given notification with the
+// warning repeat marker, reminder ordinal, removal countdown and
+// idempotency key removed, so that repeated notifications still
+// compare as equivalent.
+func stripRepeatMarker(notification *c.Notification) *c.Notification {
+	if notification == nil {
+		return nil
+	}
+	stripped := *notification
+	stripped.Context = milestoneWarningRepeatRE.ReplaceAllString(notification.Context, "")
+	stripped.Context = milestoneNotificationKeyRE.ReplaceAllString(stripped.Context, "")
+	stripped.Context = milestoneCountdownRE.ReplaceAllString(stripped.Context, "")
+	stripped.Context = strings.TrimSpace(milestoneWarningCountRE.ReplaceAllString(stripped.Context, ""))
+	return &stripped
+}
+
+// backoffInterval returns the effective warning interval for the given
+// notification comment, doubling the configured interval for every
+// repeat up to the configured cap.
+func (m *MilestoneMaintainer) backoffInterval(comment *c.Comment) time.Duration {
+	interval := m.warningInterval
+	for i := 0; i < notificationRepeatCount(comment); i++ {
+		interval *= 2
+		if interval >= m.warningBackoffCap {
+			return m.warningBackoffCap
+		}
+	}
+	return interval
+}
+
+// gracePeriodRemaining returns the difference between the start of the
+// grace period and the grace period interval as of the given time.
+// Returns nil if the grace period is not in effect.
+func gracePeriodRemaining(obj *github.MungeObject, botNames []string, labelName string, gracePeriod time.Duration, now time.Time, isBlocker bool, businessDays bool, holidays map[string]bool, fromFirstWarning bool) (*time.Duration, bool) {
+	if isBlocker {
+		return nil, true
+	}
+	tempStart, ok := gracePeriodStart(obj, botNames, labelName, now, fromFirstWarning)
+	if !ok {
+		return nil, false
+	}
+	if tempStart == nil {
+		return nil, true
+	}
+	start := *tempStart
+
+	deadline := start.Add(gracePeriod)
+	if businessDays {
+		deadline = addBusinessTime(start, gracePeriod, holidays)
+	}
+	remaining := deadline.Sub(now)
+	return &remaining, true
+}
+
+// addBusinessTime returns the moment at which the given amount of
+// business time will have elapsed from start. Weekends and the given
+// holidays do not count as business time, so the returned deadline
+// moves later for every non-business day it crosses.
+func addBusinessTime(start time.Time, gracePeriod time.Duration, holidays map[string]bool) time.Time {
+	current := start
+	remaining := gracePeriod
+	for remaining > 0 {
+		if !isBusinessDay(current, holidays) {
+			current = startOfNextDay(current)
+			continue
+		}
+		endOfDay := startOfNextDay(current)
+		available := endOfDay.Sub(current)
+		if available > remaining {
+			return current.Add(remaining)
+		}
+		remaining -= available
+		current = endOfDay
+	}
+	return current
+}
+
+// isBusinessDay indicates whether the given time falls on a weekday
+// that is not a configured holiday.
+func isBusinessDay(t time.Time, holidays map[string]bool) bool {
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	return !holidays[t.Format(milestoneBlackoutDateFormat)]
+}
+
+// startOfNextDay returns midnight of the day after the given time.
+func startOfNextDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location()).AddDate(0, 0, 1)
+}
+
+// gracePeriodStart determines when the grace period for the given
+// label should start/have started. If the label is currently set, the
+// time it was last applied by the munger will be used. Otherwise the
+// default time is returned. When fromFirstWarning is set, the earliest
+// warning notification anchors the grace period instead, so that a
+// label removed and re-added by a human does not reset the countdown.
+func gracePeriodStart(obj *github.MungeObject, botNames []string, labelName string, defaultTime time.Time, fromFirstWarning bool) (*time.Time, bool) {
+	if fromFirstWarning {
+		first, ok := firstWarningCreatedAt(obj, botNames)
+		if !ok {
+			return nil, false
+		}
+		if first != nil {
+			return first, true
+		}
+	}
+
+	if !obj.HasLabel(labelName) {
+		return &defaultTime, true
+	}
+
+	return labelLastCreatedAt(obj, botNames, labelName)
+}
+
+// firstWarningCreatedAt returns the creation time of the earliest
+// warning notification the munger has posted on the issue, or nil when
+// no notification exists.
+func firstWarningCreatedAt(obj *github.MungeObject, botNames []string) (*time.Time, bool) {
+	issueComments, ok := obj.ListComments()
+	if !ok {
+		return nil, false
+	}
+	notifications := c.FilterComments(c.FromIssueComments(issueComments), c.MungerNotificationNames(milestoneNotifierName, botNames))
+	var earliest *time.Time
+	for _, comment := range notifications {
+		if comment.CreatedAt == nil {
+			continue
+		}
+		if earliest == nil || comment.CreatedAt.Before(*earliest) {
+			earliest = comment.CreatedAt
+		}
+	}
+	return earliest, true
+}
+
+// labelLastCreatedAt returns the time at which the given label was
+// last applied to the given github object by any of the given bot
+// logins.
+func labelLastCreatedAt(obj *github.MungeObject, botNames []string, labelName string) (*time.Time, bool) {
+	events, ok := obj.GetEvents()
+	if !ok {
+		return nil, false
+	}
+
+	labelMatcher := event.And([]event.Matcher{
+		event.AddLabel{},
+		event.LabelName(labelName),
+		event.Actors(botNames),
+	})
+	labelEvents := event.FilterEvents(events, labelMatcher)
+	lastAdded := labelEvents.GetLast()
+	if lastAdded != nil {
+		return lastAdded.CreatedAt, true
+	}
+	return nil, true
+}
+
+// defaultETAPatternRE is the compiled form of defaultETAPattern, used
+// when no custom pattern has been configured.
+var defaultETAPatternRE = regexp.MustCompile(defaultETAPattern)
+
+// latestUpdateHasETA indicates whether the most recent update comment
+// from someone other than the munger or an ignored actor includes an
+// ETA line. Issues without any update comment report true, since the
+// missing update itself is already warned about.
+func (m *MilestoneMaintainer) latestUpdateHasETA(obj *github.MungeObject) bool {
+	issueComments, ok := obj.ListComments()
+	if !ok {
+		return true
+	}
+	var latest *githubapi.IssueComment
+	for _, comment := range issueComments {
+		if comment.User == nil || comment.User.Login == nil || comment.CreatedAt == nil ||
+			isBotName(*comment.User.Login, m.botNames()) || isBotName(*comment.User.Login, m.ignoredUpdateActors) {
+			continue
+		}
+		if latest == nil || comment.CreatedAt.After(*latest.CreatedAt) {
+			latest = comment
+		}
+	}
+	if latest == nil || latest.Body == nil {
+		return true
+	}
+	etaPattern := m.etaPattern
+	if etaPattern == nil {
+		etaPattern = defaultETAPatternRE
+	}
+	return etaPattern.MatchString(*latest.Body)
+}
+
+// defaultDesignDocPatternRE is the compiled form of
+// defaultDesignDocPattern, used when no custom pattern has been
+// configured.
+var defaultDesignDocPatternRE = regexp.MustCompile(defaultDesignDocPattern)
+
+// hasDesignDocLink indicates whether the issue description or any
+// comment matches the design-doc pattern. A failed comment listing
+// reports true to avoid warning on incomplete data.
+func (m *MilestoneMaintainer) hasDesignDocLink(obj *github.MungeObject) bool {
+	designDocPattern := m.designDocPattern
+	if designDocPattern == nil {
+		designDocPattern = defaultDesignDocPatternRE
+	}
+	if obj.Issue.Body != nil && designDocPattern.MatchString(*obj.Issue.Body) {
+		return true
+	}
+	issueComments, ok := obj.ListComments()
+	if !ok {
+		return true
+	}
+	for _, comment := range issueComments {
+		if comment.Body != nil && designDocPattern.MatchString(*comment.Body) {
+			return true
+		}
+	}
+	return false
+}
+
+// findLastModificationTime returns the time of the last modification
+// of the issue by someone other than the munger or an ignored actor,
+// or the creation time of the issue if it has not been modified. The url of the last
+// modification is returned when one is available (e.g. the html url of
+// a comment); modifications without a url (such as issue creation or
+// label events) yield an empty string.
+func findLastModificationTime(obj *github.MungeObject, botNames []string, ignoredActors []string) (*time.Time, string, bool) {
+	lastModification := *obj.Issue.CreatedAt
+	lastModificationURL := ""
+	issueComments, ok := obj.ListComments()
+	if !ok {
+		return nil, "", false
+	}
+	for _, comment := range issueComments {
+		if comment.User == nil || comment.User.Login == nil || isBotName(*comment.User.Login, botNames) || isBotName(*comment.User.Login, ignoredActors) {
+			continue
+		}
+		if comment.CreatedAt != nil && comment.CreatedAt.After(lastModification) {
+			lastModification = *comment.CreatedAt
+			lastModificationURL = ""
+			if comment.HTMLURL != nil {
+				lastModificationURL = *comment.HTMLURL
+			}
+		}
+	}
+	return &lastModification, lastModificationURL, true
+}
+
+// issueHasAssignee indicates whether anybody is assigned to the issue.
+func issueHasAssignee(issue *githubapi.Issue) bool {
+	if issue.Assignee != nil && issue.Assignee.Login != nil {
+		return true
+	}
+	return len(issue.Assignees) > 0
+}
+
+// requiredLabelGroup defines a group of labels of which an issue must
+// carry at least one - or exactly one - to remain in the milestone.
+type requiredLabelGroup struct {
+	// name identifies the requirement in the label error text.
+	name string
+	// labels is the set of accepted labels; ignored if prefix is set.
+	labels []string
+	// prefix accepts any label sharing the prefix.
+	prefix string
+	// exactlyOne requires exactly one matching label rather than at
+	// least one.
+	exactlyOne bool
+}
+
+// check returns the labels in the given slice that satisfy the group,
+// and an error string if the group's requirement is unmet.
+func (g *requiredLabelGroup) check(labels []githubapi.Label) (matches []string, labelError string) {
+	for _, label := range labels {
+		if label.Name == nil {
+			continue
+		}
+		if g.prefix != "" {
+			if strings.HasPrefix(*label.Name, g.prefix) {
+				matches = append(matches, *label.Name)
+			}
+			continue
+		}
+		for _, accepted := range g.labels {
+			if *label.Name == accepted {
+				matches = append(matches, *label.Name)
+				break
+			}
+		}
+	}
+
+	if g.exactlyOne && len(matches) != 1 {
+		if g.prefix != "" {
+			if len(matches) > 1 {
+				return nil, fmt.Sprintf("_**%s**_: Must specify exactly one label prefixed with `%s`, but found %d.", g.name, g.prefix, len(matches))
+			}
+			return nil, fmt.Sprintf("_**%s**_: Must specify exactly one label prefixed with `%s`.", g.name, g.prefix)
+		}
+		return nil, fmt.Sprintf("_**%s**_: Must specify exactly one of %s.", g.name, formatLabelList(g.labels))
+	}
+	if !g.exactlyOne && len(matches) == 0 {
+		if g.prefix != "" {
+			return nil, fmt.Sprintf("_**%s**_: Must specify at least one label prefixed with `%s`.", g.name, g.prefix)
+		}
+		return nil, fmt.Sprintf("_**%s**_: Must specify at least one of %s.", g.name, formatLabelList(g.labels))
+	}
+	return matches, ""
+}
+
+// defaultRequiredLabelGroups returns the groups every issue must
+// satisfy regardless of configuration: kind, priority and sig owner.
+// singleSigOwner requires exactly one owning sig label rather than at
+// least one.
+func defaultRequiredLabelGroups(singleSigOwner bool) []requiredLabelGroup {
+	return []requiredLabelGroup{
+		{name: "kind", labels: sortedLabelNames(kindMap), exactlyOne: true},
+		{name: "priority", labels: sortedLabelNames(priorityMap), exactlyOne: true},
+		{name: "sig owner", prefix: sigLabelPrefix, exactlyOne: singleSigOwner},
+	}
+}
+
+// parseRequiredLabelGroups parses additional required label groups
+// from a spec of the form
+// "name:exactly-one|at-least-one:label1|label2;name2:...". A single
+// label ending in "/" is treated as a prefix match.
+func parseRequiredLabelGroups(spec string) ([]requiredLabelGroup, error) {
+	groups := []requiredLabelGroup{}
+	for _, groupSpec := range strings.Split(spec, ";") {
+		groupSpec = strings.TrimSpace(groupSpec)
+		if groupSpec == "" {
+			continue
+		}
+		fields := strings.SplitN(groupSpec, ":", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid required label group %q: expected name:mode:labels", groupSpec)
+		}
+		group := requiredLabelGroup{name: strings.TrimSpace(fields[0])}
+		switch strings.TrimSpace(fields[1]) {
+		case "exactly-one":
+			group.exactlyOne = true
+		case "at-least-one":
+		default:
+			return nil, fmt.Errorf("invalid required label group mode %q: expected exactly-one or at-least-one", fields[1])
+		}
+		labels := strings.Split(fields[2], "|")
+		if len(labels) == 1 && strings.HasSuffix(labels[0], "/") {
+			group.prefix = labels[0]
+		} else {
+			group.labels = labels
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// checkLabels validates that the given labels are consistent with the
+// requirements for an issue remaining in its chosen milestone.
+// Returns the values of required labels (if present) and a slice of
+// errors (if labels are missing or conflicting). A non-empty native
+// issue type satisfies the kind requirement for issues that have no
+// kind label.
+func checkLabels(labels []githubapi.Label, issueType string, singleSigOwner bool, extraGroups []requiredLabelGroup) (kindLabel, priorityLabel string, sigLabels []string, labelErrors []string) {
+	if kindFromType, ok := issueTypeKindMap[strings.ToLower(issueType)]; ok && !hasKindLabel(labels) {
+		labels = append(append([]githubapi.Label{}, labels...), githubapi.Label{Name: &kindFromType})
+	}
+	labelErrors = []string{}
+	groups := append(defaultRequiredLabelGroups(singleSigOwner), extraGroups...)
+	for i := range groups {
+		group := &groups[i]
+		matches, labelError := group.check(labels)
+		if len(labelError) > 0 {
+			labelErrors = append(labelErrors, labelError)
+			continue
+		}
+		switch group.name {
+		case "kind":
+			kindLabel = matches[0]
+		case "priority":
+			priorityLabel = matches[0]
+		case "sig owner":
+			sigLabels = matches
+		}
+	}
+	return
+}
+
+// hasKindLabel indicates whether any of the given labels is a kind
+// label.
+func hasKindLabel(labels []githubapi.Label) bool {
+	for _, label := range labels {
+		if label.Name == nil {
+			continue
+		}
+		if _, ok := kindMap[*label.Name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// snoozedUntil returns the latest date embedded in a snooze label on
+// the issue, or nil if the issue carries no well-formed snooze label.
+// Snooze labels whose date fails to parse are logged and ignored.
+func (m *MilestoneMaintainer) snoozedUntil(obj *github.MungeObject) *time.Time {
+	if len(m.snoozeLabelPrefix) == 0 {
+		return nil
+	}
+	var until *time.Time
+	for _, label := range obj.Issue.Labels {
+		if label.Name == nil || !strings.HasPrefix(*label.Name, m.snoozeLabelPrefix) {
+			continue
+		}
+		date, err := time.Parse(milestoneBlackoutDateFormat, strings.TrimPrefix(*label.Name, m.snoozeLabelPrefix))
+		if err != nil {
+			glog.Warningf("Ignoring malformed snooze label %q on issue %d: %v", *label.Name, obj.Number(), err)
+			continue
+		}
+		if until == nil || date.After(*until) {
+			snoozeDate := date
+			until = &snoozeDate
+		}
+	}
+	return until
+}
+
+// hasAnyRecognizedLabel indicates whether any of the given labels is a
+// kind, priority or sig label. An issue with none of the three is
+// considered untriaged rather than incompletely labeled.
+func hasAnyRecognizedLabel(labels []githubapi.Label) bool {
+	for _, label := range labels {
+		if label.Name == nil {
+			continue
+		}
+		if _, ok := kindMap[*label.Name]; ok {
+			return true
+		}
+		if _, ok := priorityMap[*label.Name]; ok {
+			return true
+		}
+		if strings.HasPrefix(*label.Name, sigLabelPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// sigLabelNames returns a slice of the names of sig labels in the
+// given slice.
+func sigLabelNames(labels []githubapi.Label) []string {
+	labelNames := []string{}
+	for _, label := range labels {
+		if label.Name != nil && strings.HasPrefix(*label.Name, sigLabelPrefix) {
+			labelNames = append(labelNames, *label.Name)
+		}
+	}
+	return labelNames
+}
+
+// sortedLabelNames returns the sorted keys of a label description map.
+func sortedLabelNames(labelMap map[string]string) []string {
+	labelList := []string{}
+	for k := range labelMap {
+		labelList = append(labelList, k)
+	}
+	sort.Strings(labelList)
+	return labelList
+}
+
+// formatLabelList converts a slice of label names to a string in the
+// format "`label-foo`, `label-bar` or `label-baz`".
+func formatLabelList(labels []string) string {
+	labelList := []string{}
+	for _, label := range labels {
+		labelList = append(labelList, quoteLabel(label))
+	}
+	sort.Strings(labelList)
+
+	maxIndex := len(labelList) - 1
+	if maxIndex == 0 {
+		return labelList[0]
+	}
+	return strings.Join(labelList[0:maxIndex], ", ") + " or " + labelList[maxIndex]
+}
+
+// quoteLabel formats a label name as inline code in markdown (e.g. `labelName`)
+func quoteLabel(label string) string {
+	if len(label) > 0 {
+		return fmt.Sprintf("`%s`", label)
+	}
+	return label
+}
+
+// milestoneStateWebhookTimeout bounds how long a state webhook POST
+// may take before it is abandoned.
+const milestoneStateWebhookTimeout = 10 * time.Second
+
+// milestoneStateWebhookPayload is the JSON payload POSTed to the state
+// webhook when an issue's computed state changes.
+type milestoneStateWebhookPayload struct {
+	Issue     int    `json:"issue"`
+	OldState  string `json:"old-state,omitempty"`
+	NewState  string `json:"new-state"`
+	Mode      string `json:"mode"`
+	Milestone string `json:"milestone"`
+}
+
+// syncStaleLabel applies or removes the stale label according to the
+// time since the issue was last modified by a human. Staleness is
+// independent of the milestone state labels and the blocker update
+// intervals, applies in every mode, and never posts a comment.
+func (m *MilestoneMaintainer) syncStaleLabel(obj *github.MungeObject) {
+	if m.staleThreshold <= 0 {
+		return
+	}
+	lastModified, _, ok := findLastModificationTime(obj, m.botNames(), m.ignoredUpdateActors)
+	if !ok {
+		return
+	}
+	stale := m.now().Sub(*lastModified) >= m.staleThreshold
+	if stale && !obj.HasLabel(milestoneStaleLabel) {
+		if err := obj.AddLabel(milestoneStaleLabel); err != nil {
+			glog.Errorf("Failed to apply %q to %d: %v", milestoneStaleLabel, obj.Number(), err)
+		}
+	} else if !stale && obj.HasLabel(milestoneStaleLabel) {
+		if err := obj.RemoveLabel(milestoneStaleLabel); err != nil {
+			glog.Errorf("Failed to remove %q from %d: %v", milestoneStaleLabel, obj.Number(), err)
+		}
+	}
+}
+
+// syncAttentionColumn keeps the configured project board column in
+// step with the needs-attention state: the issue's card is added to
+// the column on entering the state and removed once resolved. Board
+// failures are logged but never block munging.
+func (m *MilestoneMaintainer) syncAttentionColumn(obj *github.MungeObject, state milestoneState) {
+	if m.attentionColumnID == 0 {
+		return
+	}
+	columnID := int64(m.attentionColumnID)
+	var err error
+	if state == milestoneNeedsAttention {
+		err = obj.AddToProjectColumn(columnID)
+	} else {
+		err = obj.RemoveFromProjectColumn(columnID)
+	}
+	if err != nil {
+		glog.Errorf("Failed to sync %d with the attention column: %v", obj.Number(), err)
+	}
+}
+
+// notifyStateWebhook pushes the state change to the configured webhook
+// without blocking the munge.
+func (m *MilestoneMaintainer) notifyStateWebhook(obj *github.MungeObject, oldLabels []string, state milestoneState) {
+	if m.stateWebhookURL == "" {
+		return
+	}
+	oldState := ""
+	if len(oldLabels) > 0 {
+		oldState = stateNameForLabel(oldLabels[0])
+	}
+	payload := milestoneStateWebhookPayload{
+		Issue:     obj.Number(),
+		OldState:  oldState,
+		NewState:  state.String(),
+		Mode:      m.effectiveMode(m.now()),
+		Milestone: m.activeMilestoneFor(obj),
+	}
+	go m.postStateWebhook(payload)
+}
+
+// postStateWebhook POSTs the payload to the state webhook. Failures
+// are logged and otherwise ignored; the webhook is best-effort.
+func (m *MilestoneMaintainer) postStateWebhook(payload milestoneStateWebhookPayload) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		glog.Errorf("Failed to encode state webhook payload %+v: %v", payload, err)
+		return
+	}
+	client := &http.Client{Timeout: milestoneStateWebhookTimeout}
+	response, err := client.Post(m.stateWebhookURL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		glog.Errorf("Failed to post state webhook: %v", err)
+		return
+	}
+	response.Body.Close()
+}
+
+// stateNameForLabel returns the name of the milestone state whose
+// label matches the given label, or the empty string.
+func stateNameForLabel(label string) string {
+	for state, config := range milestoneStateConfigs {
+		if config.label == label {
+			return state.String()
+		}
+	}
+	return ""
+}
+
+// reconcileStateLabels surfaces the presence of multiple milestone
+// state labels, which can occur when a human applies one manually
+// alongside the label maintained by the munger. The conflict is only
+// logged here; the state computed during this munge wins
+// deterministically when the labels are updated.
+func (m *MilestoneMaintainer) reconcileStateLabels(obj *github.MungeObject) {
+	if stateLabels := currentStateLabels(obj); len(stateLabels) > 1 {
+		glog.Warningf("Issue %d has conflicting milestone state labels %v; the computed state will win", obj.Number(), stateLabels)
+	}
+}
+
+// stateLabelDiff computes the additions and removals needed to make
+// labelName the only milestone state label on an issue currently
+// bearing the given labels. An empty labelName yields removals only.
+func stateLabelDiff(labels []githubapi.Label, labelName string) (adds, removes []string) {
+	current := sets.NewString()
+	for _, label := range labels {
+		if label.Name != nil {
+			current.Insert(*label.Name)
+		}
+	}
+	for _, stateLabel := range milestoneStateLabels {
+		if stateLabel != labelName && current.Has(stateLabel) {
+			removes = append(removes, stateLabel)
+		}
+	}
+	if len(labelName) > 0 && !current.Has(labelName) {
+		adds = append(adds, labelName)
+	}
+	return adds, removes
+}
+
+// updateMilestoneStateLabel ensures that the given milestone state
+// label is the only state label set on the given issue. Stale labels
+// are removed before the new label is added so that a partial failure
+// never leaves the issue with conflicting state labels; every removal
+// is attempted even if one fails, and the next loop retries whatever
+// remains.
+func (m *MilestoneMaintainer) updateMilestoneStateLabel(obj *github.MungeObject, labelName string) bool {
+	adds, removes := stateLabelDiff(obj.Issue.Labels, labelName)
+	if m.config != nil && m.config.DryRun && (len(adds) > 0 || len(removes) > 0) {
+		// For dry-run audits, report the full label diff the live run
+		// would apply.
+		glog.Infof("Dry-run label diff for %d: add %v, remove %v", obj.Number(), adds, removes)
+	}
+	removalsComplete := true
+	for _, stateLabel := range removes {
+		stateLabel := stateLabel
+		if err := m.retryMutation(fmt.Sprintf("removing label %q", stateLabel), func() error {
+			return obj.RemoveLabel(stateLabel)
+		}); err != nil {
+			removalsComplete = false
+		}
+	}
+	if !removalsComplete {
+		return false
+	}
+	for _, stateLabel := range adds {
+		stateLabel := stateLabel
+		if err := m.retryMutation(fmt.Sprintf("adding label %q", stateLabel), func() error {
+			return obj.AddLabel(stateLabel)
+		}); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// durationToMaxDays converts a duration to a string indicating the
+// maximum number of days represented by the duration.
+func durationToMaxDays(duration time.Duration) string {
+	days := math.Ceil(duration.Hours() / 24)
+	dayString := "days"
+	if days == 1 {
+		dayString = "day"
+	}
+	return fmt.Sprintf("%v %s", days, dayString)
+}
+
+// durationToDaysAndHours converts a duration to a human-readable
+// string, rendering sub-day durations in hours and combining days and
+// hours for mixed values. Whole-day durations keep the day-only form
+// of durationToMaxDays.
+func durationToDaysAndHours(duration time.Duration) string {
+	hours := int(math.Ceil(duration.Hours()))
+	days := hours / 24
+	remainder := hours % 24
+	if remainder == 0 && days > 0 {
+		return durationToMaxDays(duration)
+	}
+	hourString := "hours"
+	if remainder == 1 {
+		hourString = "hour"
+	}
+	if days == 0 {
+		return fmt.Sprintf("%d %s", remainder, hourString)
+	}
+	dayString := "days"
+	if days == 1 {
+		dayString = "day"
+	}
+	return fmt.Sprintf("%d %s %d %s", days, dayString, remainder, hourString)
+}
@@ -0,0 +1,4305 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mungers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	githubapi "github.com/google/go-github/github"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/test-infra/mungegithub/features"
+	"k8s.io/test-infra/mungegithub/github"
+	github_test "k8s.io/test-infra/mungegithub/github/testing"
+	c "k8s.io/test-infra/mungegithub/mungers/matchers/comment"
+	"k8s.io/test-infra/mungegithub/options"
+)
+
+const milestoneTestBotName = "test-bot"
+
+func milestoneTestMaintainer(mode string) *MilestoneMaintainer {
+	return NewTestMilestoneMaintainer(MilestoneMaintainerSpec{
+		BotName:         milestoneTestBotName,
+		ActiveMilestone: "v1.10",
+		Mode:            mode,
+		FreezeDate:      "Nov 22",
+	})
+}
+
+func milestoneTestObject(labels []string, milestone string, comments []*githubapi.IssueComment, events []*githubapi.IssueEvent) *github.MungeObject {
+	issue := github_test.Issue("user", 1, labels, false)
+	if milestone != "" {
+		issue.Milestone = &githubapi.Milestone{Title: &milestone}
+	}
+	config := &github.Config{
+		Org:     "org",
+		Project: "project",
+		BotName: milestoneTestBotName,
+		DryRun:  true,
+	}
+	return github.NewTestObject(config, issue, comments, events)
+}
+
+func TestIgnoreObject(t *testing.T) {
+	tests := map[string]struct {
+		isPR      bool
+		state     string
+		locked    bool
+		milestone string
+		expected  bool
+	}{
+		"ignore pull requests":                    {isPR: true, milestone: "v1.10", expected: true},
+		"ignore closed issues":                    {state: "closed", milestone: "v1.10", expected: true},
+		"ignore locked issues":                    {locked: true, milestone: "v1.10", expected: true},
+		"ignore issues without a milestone":       {expected: true},
+		"ignore issues in a different milestone":  {milestone: "v1.9", expected: true},
+		"consider issues in the active milestone": {milestone: "v1.10", expected: false},
+	}
+	for testName, test := range tests {
+		issue := github_test.Issue("user", 1, nil, test.isPR)
+		if test.state != "" {
+			issue.State = &test.state
+		}
+		if test.locked {
+			issue.Locked = &test.locked
+		}
+		if test.milestone != "" {
+			issue.Milestone = &githubapi.Milestone{Title: &test.milestone}
+		}
+		obj := github.NewTestObject(&github.Config{DryRun: true}, issue, nil, nil)
+		if ignored := ignoreObject(obj, "v1.10", nil, false, blockerLabel, false, nil, nil); ignored != test.expected {
+			t.Errorf("%s: expected ignoreObject to return %t, got %t", testName, test.expected, ignored)
+		}
+	}
+}
+
+func TestReaddRemovedBlockers(t *testing.T) {
+	tests := map[string]struct {
+		autoReadd     bool
+		labels        []string
+		expectReadded bool
+	}{
+		"removed blocker is re-added": {
+			autoReadd:     true,
+			labels:        []string{milestoneRemovedLabel, blockerLabel},
+			expectReadded: true,
+		},
+		"removed non-blocker stays removed": {
+			autoReadd: true,
+			labels:    []string{milestoneRemovedLabel},
+		},
+		"blocker without the removed label stays out": {
+			autoReadd: true,
+			labels:    []string{blockerLabel},
+		},
+		"removed blocker stays removed when the option is disabled": {
+			labels: []string{milestoneRemovedLabel, blockerLabel},
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeFreeze)
+		m.autoReaddBlockers = test.autoReadd
+		obj := milestoneTestObject(test.labels, "", nil, nil)
+
+		m.Munge(obj)
+
+		milestone, _ := obj.ReleaseMilestone()
+		if test.expectReadded {
+			if milestone != m.activeMilestone {
+				t.Errorf("%s: expected milestone %q, got %q", testName, m.activeMilestone, milestone)
+			}
+			if obj.HasLabel(milestoneRemovedLabel) {
+				t.Errorf("%s: expected %q to be removed", testName, milestoneRemovedLabel)
+			}
+		} else {
+			if milestone != "" {
+				t.Errorf("%s: expected issue to stay out of the milestone, got %q", testName, milestone)
+			}
+			if obj.HasLabel(milestoneRemovedLabel) != hasString(test.labels, milestoneRemovedLabel) {
+				t.Errorf("%s: expected %q to be left unchanged", testName, milestoneRemovedLabel)
+			}
+		}
+	}
+}
+
+func hasString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCheckLabelsExtraGroups(t *testing.T) {
+	triageGroup := requiredLabelGroup{name: "triage", labels: []string{"triage/accepted"}}
+	completeLabels := []string{"kind/bug", "priority/important-soon", "sig/node"}
+
+	tests := map[string]struct {
+		labels        []string
+		expectedError string
+	}{
+		"missing custom group label produces its error": {
+			labels:        completeLabels,
+			expectedError: "_**triage**_: Must specify at least one of `triage/accepted`.",
+		},
+		"custom group label satisfies the requirement": {
+			labels: append([]string{"triage/accepted"}, completeLabels...),
+		},
+	}
+	for testName, test := range tests {
+		issue := github_test.Issue("user", 1, test.labels, false)
+		_, _, _, labelErrors := checkLabels(issue.Labels, "", false, []requiredLabelGroup{triageGroup})
+		if test.expectedError == "" {
+			if len(labelErrors) != 0 {
+				t.Errorf("%s: expected no label errors, got %v", testName, labelErrors)
+			}
+			continue
+		}
+		if len(labelErrors) != 1 || labelErrors[0] != test.expectedError {
+			t.Errorf("%s: expected label error %q, got %v", testName, test.expectedError, labelErrors)
+		}
+	}
+}
+
+func TestParseRequiredLabelGroups(t *testing.T) {
+	groups, err := parseRequiredLabelGroups("triage:at-least-one:triage/accepted;area:exactly-one:area/api|area/kubelet;wg:at-least-one:wg/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(groups))
+	}
+	if groups[1].name != "area" || !groups[1].exactlyOne || len(groups[1].labels) != 2 {
+		t.Errorf("unexpected area group: %+v", groups[1])
+	}
+	if groups[2].prefix != "wg/" {
+		t.Errorf("expected a prefix group, got %+v", groups[2])
+	}
+	if _, err := parseRequiredLabelGroups("bad-spec"); err == nil {
+		t.Errorf("expected an error for an invalid spec")
+	}
+}
+
+func TestIssueChangeConfigState(t *testing.T) {
+	completeLabels := []string{"kind/bug", "priority/important-soon", "sig/node"}
+	approvedLabels := append([]string{statusApprovedLabel}, completeLabels...)
+	blockerLabels := []string{"kind/bug", blockerLabel, "sig/node", statusApprovedLabel}
+
+	tests := map[string]struct {
+		mode     string
+		labels   []string
+		expected milestoneState
+	}{
+		"incomplete labels warn": {
+			mode:     milestoneModeDev,
+			labels:   []string{"sig/node"},
+			expected: milestoneNeedsLabeling,
+		},
+		"complete but unapproved labels warn": {
+			mode:     milestoneModeDev,
+			labels:   completeLabels,
+			expected: milestoneNeedsApproval,
+		},
+		"approved issue is current during dev": {
+			mode:     milestoneModeDev,
+			labels:   approvedLabels,
+			expected: milestoneCurrent,
+		},
+		"non-blocker warns during slush": {
+			mode:     milestoneModeSlush,
+			labels:   approvedLabels,
+			expected: milestoneNeedsAttention,
+		},
+		"non-blocker is removed during freeze": {
+			mode:     milestoneModeFreeze,
+			labels:   approvedLabels,
+			expected: milestoneNeedsRemoval,
+		},
+		"blocker without in-progress needs attention during slush": {
+			mode:     milestoneModeSlush,
+			labels:   blockerLabels,
+			expected: milestoneNeedsAttention,
+		},
+		"in-progress blocker is current during freeze": {
+			mode:     milestoneModeFreeze,
+			labels:   append([]string{statusInProgressLabel}, blockerLabels...),
+			expected: milestoneCurrent,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(test.mode)
+		// An up-to-date comment from a human actor ensures blockers
+		// are not flagged as requiring an update.
+		comments := []*githubapi.IssueComment{
+			issueComment(1, "user", "working on it", time.Now()),
+		}
+		obj := milestoneTestObject(test.labels, m.activeMilestone, comments, nil)
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Errorf("%s: expected an issueChangeConfig, got nil", testName)
+			continue
+		}
+		if icc.state != test.expected {
+			t.Errorf("%s: expected state %v, got %v", testName, test.expected, icc.state)
+		}
+		if body := icc.messageBody(); body == nil {
+			t.Errorf("%s: expected a message body, got nil", testName)
+		}
+	}
+}
+
+func TestMungeMilestoneMaintainer(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeFreeze)
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel}
+	obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+
+	m.Munge(obj)
+
+	if !obj.HasLabel(milestoneRemovedLabel) {
+		t.Errorf("expected %q to be applied", milestoneRemovedLabel)
+	}
+	if milestone, _ := obj.ReleaseMilestone(); milestone != "" {
+		t.Errorf("expected milestone to be cleared, got %q", milestone)
+	}
+	comment, ok := latestNotificationComment(obj, []string{milestoneTestBotName})
+	if !ok || comment == nil {
+		t.Fatalf("expected a notification comment to be posted")
+	}
+}
+
+func TestSuppressSummary(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeDev)
+	m.suppressSummary = true
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel, milestoneLabelsIncompleteLabel}
+	obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+
+	m.Munge(obj)
+
+	if comment, ok := latestNotificationComment(obj, []string{milestoneTestBotName}); !ok || comment != nil {
+		t.Errorf("expected no notification comment on a healthy issue")
+	}
+	if obj.HasLabel(milestoneLabelsIncompleteLabel) {
+		t.Errorf("expected stale state label %q to be removed", milestoneLabelsIncompleteLabel)
+	}
+}
+
+func TestKeepWithOpenPR(t *testing.T) {
+	crossReferenced := "cross-referenced"
+	linkTimeline := func(number int) []*githubapi.Timeline {
+		url := fmt.Sprintf("https://api.github.com/repos/org/project/issues/%d", number)
+		return []*githubapi.Timeline{{Event: &crossReferenced, Source: &githubapi.Source{URL: &url}}}
+	}
+
+	openPR := github_test.Issue("author", 99, nil, true)
+	closedPR := github_test.Issue("author", 98, nil, true)
+	closedState := "closed"
+	closedPR.State = &closedState
+
+	tests := map[string]struct {
+		keepWithOpenPR bool
+		timeline       []*githubapi.Timeline
+		referenced     []*githubapi.Issue
+		expected       milestoneState
+	}{
+		"open linked PR keeps the issue in the milestone": {
+			keepWithOpenPR: true,
+			timeline:       linkTimeline(99),
+			referenced:     []*githubapi.Issue{openPR},
+			expected:       milestoneNeedsAttention,
+		},
+		"closed linked PR does not": {
+			keepWithOpenPR: true,
+			timeline:       linkTimeline(98),
+			referenced:     []*githubapi.Issue{closedPR},
+			expected:       milestoneNeedsRemoval,
+		},
+		"no linked PR does not": {
+			keepWithOpenPR: true,
+			expected:       milestoneNeedsRemoval,
+		},
+		"the option off removes regardless": {
+			timeline:   linkTimeline(99),
+			referenced: []*githubapi.Issue{openPR},
+			expected:   milestoneNeedsRemoval,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeFreeze)
+		m.keepWithOpenPR = test.keepWithOpenPR
+		labels := []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel}
+		issue := github_test.Issue("user", 1, labels, false)
+		issue.Milestone = &githubapi.Milestone{Title: &m.activeMilestone}
+		config := &github.Config{Org: "org", Project: "project", BotName: milestoneTestBotName, DryRun: true}
+		obj := github.NewTestObjectWithTimeline(config, issue, nil, nil, test.timeline, test.referenced)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if icc.state != test.expected {
+			t.Errorf("%s: expected state %v, got %v", testName, test.expected, icc.state)
+		}
+	}
+}
+
+func TestConcurrentOptionReload(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeDev)
+	opts := options.New()
+	m.RegisterOptions(opts)
+	opts.PopulateFromString(milestoneOptActiveMilestone + "=v1.10")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			opts.PopulateFromString(milestoneOptActiveMilestone + "=v1.10\n" + milestoneOptMode + "=" + milestoneModeSlush)
+			opts.PopulateFromString(milestoneOptActiveMilestone + "=v1.10\n" + milestoneOptMode + "=" + milestoneModeFreeze)
+		}
+	}()
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel}
+	for i := 0; i < 100; i++ {
+		m.Munge(milestoneTestObject(labels, "v1.10", nil, nil))
+	}
+	<-done
+}
+
+func TestCheckLabelsIssueTypes(t *testing.T) {
+	baseLabels := []string{"priority/important-soon", "sig/node"}
+
+	tests := map[string]struct {
+		labels       []string
+		issueType    string
+		expectedKind string
+		expectError  bool
+	}{
+		"kind label alone satisfies the requirement": {
+			labels:       append([]string{"kind/bug"}, baseLabels...),
+			expectedKind: "kind/bug",
+		},
+		"issue type alone satisfies the requirement": {
+			labels:       baseLabels,
+			issueType:    "Bug",
+			expectedKind: "kind/bug",
+		},
+		"kind label wins when both are present": {
+			labels:       append([]string{"kind/cleanup"}, baseLabels...),
+			issueType:    "Bug",
+			expectedKind: "kind/cleanup",
+		},
+		"unknown issue type does not": {
+			labels:      baseLabels,
+			issueType:   "Epic",
+			expectError: true,
+		},
+		"no kind at all errors": {
+			labels:      baseLabels,
+			expectError: true,
+		},
+	}
+	for testName, test := range tests {
+		issue := github_test.Issue("user", 1, test.labels, false)
+		kind, _, _, labelErrors := checkLabels(issue.Labels, test.issueType, false, nil)
+		if (len(labelErrors) > 0) != test.expectError {
+			t.Errorf("%s: expected error %t, got %v", testName, test.expectError, labelErrors)
+		}
+		if kind != test.expectedKind {
+			t.Errorf("%s: expected kind %q, got %q", testName, test.expectedKind, kind)
+		}
+	}
+}
+
+func TestCustomBlockerLabel(t *testing.T) {
+	customBlocker := "priority/release-blocker"
+	priorityMap[customBlocker] = priorityMap[blockerLabel]
+	defer delete(priorityMap, customBlocker)
+
+	m := milestoneTestMaintainer(milestoneModeFreeze)
+	m.blockerLabel = customBlocker
+
+	tests := map[string]struct {
+		labels   []string
+		expected milestoneState
+	}{
+		"custom blocker label keeps the issue during freeze": {
+			labels:   []string{"kind/bug", customBlocker, "sig/node", statusApprovedLabel, statusInProgressLabel},
+			expected: milestoneNeedsAttention,
+		},
+		"the default blocker label no longer counts": {
+			labels:   []string{"kind/bug", blockerLabel, "sig/node", statusApprovedLabel, statusInProgressLabel},
+			expected: milestoneNeedsRemoval,
+		},
+	}
+	for testName, test := range tests {
+		obj := milestoneTestObject(test.labels, m.activeMilestone, nil, nil)
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if icc.state != test.expected {
+			t.Errorf("%s: expected state %v, got %v", testName, test.expected, icc.state)
+		}
+	}
+}
+
+func TestStateWebhook(t *testing.T) {
+	payloads := make(chan milestoneStateWebhookPayload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := milestoneStateWebhookPayload{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("unable to decode webhook payload: %v", err)
+		}
+		payloads <- payload
+	}))
+	defer server.Close()
+
+	m := milestoneTestMaintainer(milestoneModeDev)
+	m.stateWebhookURL = server.URL
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node", milestoneNeedsAttentionLabel}
+	obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+
+	m.Munge(obj)
+
+	select {
+	case payload := <-payloads:
+		expected := milestoneStateWebhookPayload{
+			Issue:     1,
+			OldState:  "needs-attention",
+			NewState:  "needs-approval",
+			Mode:      milestoneModeDev,
+			Milestone: "v1.10",
+		}
+		if payload != expected {
+			t.Errorf("expected payload %+v, got %+v", expected, payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected a webhook POST")
+	}
+}
+
+func TestGracePeriodCountdown(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeDev)
+	// The fake clock starts at the wall clock so that comment ages -
+	// recorded with the wall clock - stay consistent with it.
+	labelTime := time.Now()
+	clock := &fakeClock{current: labelTime}
+	m.clock = clock
+
+	labels := []string{"sig/node", milestoneLabelsIncompleteLabel}
+	events := []*githubapi.IssueEvent{
+		milestoneLabelEvent(milestoneLabelsIncompleteLabel, milestoneTestBotName, labelTime),
+	}
+	obj := milestoneTestObject(labels, m.activeMilestone, nil, events)
+
+	body := func() string {
+		comment, ok := latestNotificationComment(obj, []string{milestoneTestBotName})
+		if !ok || comment == nil {
+			t.Fatalf("expected a notification comment")
+		}
+		return *comment.Source.(*githubapi.IssueComment).Body
+	}
+
+	m.Munge(obj)
+	if got := body(); !strings.Contains(got, fmt.Sprintf(milestoneCountdownTemplate, "3 days")) {
+		t.Fatalf("expected the warning to mention 'within 3 days', got:\n%s", got)
+	}
+
+	// Once the warning interval has elapsed, the reminder is reposted
+	// with a re-rendered remaining time.
+	clock.current = labelTime.Add(2*day + time.Hour)
+	m.Munge(obj)
+	if got := body(); !strings.Contains(got, fmt.Sprintf(milestoneCountdownTemplate, "23 hours")) {
+		t.Errorf("expected the warning to count down to 'within 23 hours', got:\n%s", got)
+	}
+}
+
+func TestConflictingStateLabels(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeDev)
+	// The bot considers the issue unapproved while a human has also
+	// applied the needs-attention label.
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node",
+		milestoneNeedsAttentionLabel, milestoneNeedsApprovalLabel}
+	obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+
+	m.Munge(obj)
+
+	if !obj.HasLabel(milestoneNeedsApprovalLabel) {
+		t.Errorf("expected computed state label %q to remain", milestoneNeedsApprovalLabel)
+	}
+	if obj.HasLabel(milestoneNeedsAttentionLabel) {
+		t.Errorf("expected conflicting state label %q to be removed", milestoneNeedsAttentionLabel)
+	}
+	if remaining := currentStateLabels(obj); len(remaining) != 1 {
+		t.Errorf("expected exactly one state label, got %v", remaining)
+	}
+}
+
+func TestSigAllowlist(t *testing.T) {
+	tests := map[string]struct {
+		spec          string
+		labels        []string
+		expectMunged  bool
+		expectedError bool
+	}{
+		"empty allowlist munges everything": {
+			labels:       []string{"sig/storage"},
+			expectMunged: true,
+		},
+		"allowlisted sig is munged": {
+			spec:         "sig/node,sig/storage",
+			labels:       []string{"sig/storage"},
+			expectMunged: true,
+		},
+		"non-allowlisted sig is skipped": {
+			spec:   "sig/node",
+			labels: []string{"sig/storage"},
+		},
+		"issue without sig labels is skipped": {
+			spec: "sig/node",
+		},
+		"labels without the sig prefix are rejected": {
+			spec:          "node",
+			expectedError: true,
+		},
+	}
+	for testName, test := range tests {
+		allowlist, err := parseSigAllowlist(test.spec)
+		if (err != nil) != test.expectedError {
+			t.Errorf("%s: expected error %t, got %v", testName, test.expectedError, err)
+		}
+		if err != nil {
+			continue
+		}
+		m := milestoneTestMaintainer(milestoneModeDev)
+		m.sigAllowlist = allowlist
+		labels := append([]string{"kind/bug", "priority/important-soon"}, test.labels...)
+		obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+
+		m.Munge(obj)
+
+		comment, ok := latestNotificationComment(obj, []string{milestoneTestBotName})
+		if !ok {
+			t.Fatalf("%s: unable to fetch comments", testName)
+		}
+		if munged := comment != nil; munged != test.expectMunged {
+			t.Errorf("%s: expected munged %t, got %t", testName, test.expectMunged, munged)
+		}
+	}
+}
+
+func TestLabelsOnly(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeFreeze)
+	m.labelsOnly = true
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel}
+	obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+
+	m.Munge(obj)
+
+	if !obj.HasLabel(milestoneRemovedLabel) {
+		t.Errorf("expected %q to be applied", milestoneRemovedLabel)
+	}
+	if milestone, _ := obj.ReleaseMilestone(); milestone != "" {
+		t.Errorf("expected milestone to be cleared, got %q", milestone)
+	}
+	if comment, ok := latestNotificationComment(obj, []string{milestoneTestBotName}); !ok || comment != nil {
+		t.Errorf("expected no notification comment in labels-only mode")
+	}
+}
+
+func TestMungeSkipsLockedIssue(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeFreeze)
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel}
+	obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+	locked := true
+	obj.Issue.Locked = &locked
+
+	m.Munge(obj)
+
+	if comment, ok := latestNotificationComment(obj, []string{milestoneTestBotName}); !ok || comment != nil {
+		t.Errorf("expected no notification comment on a locked issue")
+	}
+	if milestone, _ := obj.ReleaseMilestone(); milestone != m.activeMilestone {
+		t.Errorf("expected locked issue to keep its milestone, got %q", milestone)
+	}
+}
+
+func TestResolvedNotification(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeDev)
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel, milestoneNeedsApprovalLabel}
+	events := []*githubapi.IssueEvent{
+		milestoneLabelEvent(milestoneNeedsApprovalLabel, milestoneTestBotName, time.Now().Add(-day)),
+	}
+	obj := milestoneTestObject(labels, m.activeMilestone, nil, events)
+
+	m.Munge(obj)
+
+	if obj.HasLabel(milestoneNeedsApprovalLabel) {
+		t.Errorf("expected %q to be removed", milestoneNeedsApprovalLabel)
+	}
+	comment, ok := latestNotificationComment(obj, []string{milestoneTestBotName})
+	if !ok || comment == nil {
+		t.Fatalf("expected a notification comment to be posted")
+	}
+	notification := c.ParseNotification(comment)
+	if notification == nil || notification.Arguments != milestoneResolvedTitle {
+		t.Fatalf("expected a resolved notification, got %v", notification)
+	}
+
+	// A second pass must not replace the resolved acknowledgement.
+	m.Munge(obj)
+	comment, _ = latestNotificationComment(obj, []string{milestoneTestBotName})
+	if notification := c.ParseNotification(comment); notification == nil || notification.Arguments != milestoneResolvedTitle {
+		t.Errorf("expected the resolved notification to be preserved, got %v", notification)
+	}
+}
+
+func TestIssueChangeConfigAtRisk(t *testing.T) {
+	blockerLabels := []string{"kind/bug", blockerLabel, "sig/node", statusApprovedLabel, statusInProgressLabel}
+
+	tests := map[string]struct {
+		atRiskPercent   int
+		sinceUpdate     time.Duration
+		expected        milestoneState
+		expectedAtRisk  bool
+		expectedOverdue bool
+	}{
+		"recently updated issue is current": {
+			atRiskPercent: 75,
+			sinceUpdate:   day,
+			expected:      milestoneCurrent,
+		},
+		"issue just under the threshold is still current": {
+			atRiskPercent: 75,
+			sinceUpdate:   3*day*75/100 - time.Minute,
+			expected:      milestoneCurrent,
+		},
+		"issue past the threshold is at risk": {
+			atRiskPercent:  75,
+			sinceUpdate:    3*day*75/100 + time.Hour,
+			expected:       milestoneAtRisk,
+			expectedAtRisk: true,
+		},
+		"issue past the full interval needs attention": {
+			atRiskPercent:   75,
+			sinceUpdate:     3*day + time.Hour,
+			expected:        milestoneNeedsAttention,
+			expectedOverdue: true,
+		},
+		"zero percent disables the at-risk state": {
+			sinceUpdate: 3*day*75/100 + time.Hour,
+			expected:    milestoneCurrent,
+		},
+		"one hundred percent disables the at-risk state": {
+			atRiskPercent: 100,
+			sinceUpdate:   3*day - time.Hour,
+			expected:      milestoneCurrent,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeSlush)
+		m.atRiskPercent = test.atRiskPercent
+		comments := []*githubapi.IssueComment{
+			issueComment(1, "user", "working on it", time.Now().Add(-test.sinceUpdate)),
+		}
+		obj := milestoneTestObject(blockerLabels, m.activeMilestone, comments, nil)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if icc.state != test.expected {
+			t.Errorf("%s: expected state %v, got %v", testName, test.expected, icc.state)
+		}
+		if icc.enabledSections.Has("warnUpdateAtRisk") != test.expectedAtRisk {
+			t.Errorf("%s: expected warnUpdateAtRisk=%t", testName, test.expectedAtRisk)
+		}
+		if icc.enabledSections.Has("warnUpdateRequired") != test.expectedOverdue {
+			t.Errorf("%s: expected warnUpdateRequired=%t", testName, test.expectedOverdue)
+		}
+	}
+}
+
+type recordingAuditSink struct {
+	records []milestoneAuditRecord
+	err     error
+}
+
+func (s *recordingAuditSink) Record(record milestoneAuditRecord) error {
+	s.records = append(s.records, record)
+	return s.err
+}
+
+func TestUnassignedIssueMentionsSigs(t *testing.T) {
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel}
+
+	for _, enabled := range []bool{true, false} {
+		m := milestoneTestMaintainer(milestoneModeDev)
+		m.mentionSigsWhenUnassigned = enabled
+		obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+		obj.Issue.User = nil
+
+		change := m.issueChange(obj)
+		if change == nil {
+			t.Fatalf("expected an issue change")
+		}
+		mentioned := strings.Contains(change.notification.Context, "@kubernetes/sig-node-misc")
+		if mentioned != enabled {
+			t.Errorf("enabled=%t: expected sig mention=%t, got:\n%s", enabled, enabled, change.notification.Context)
+		}
+	}
+}
+
+func TestSigMentionsDeduplicated(t *testing.T) {
+	// A truncating template maps several sig labels to one handle.
+	mentions := sigMentions([]string{"sig/network", "sig/net", "sig/node"}, "@kubernetes/%.3s-leads", nil)
+	expected := "@kubernetes/net-leads @kubernetes/nod-leads"
+	if mentions != expected {
+		t.Errorf("expected mentions %q, got %q", expected, mentions)
+	}
+}
+
+func TestGracePeriodFloor(t *testing.T) {
+	incompleteLabels := []string{"sig/node", milestoneLabelsIncompleteLabel}
+
+	tests := map[string]struct {
+		labelAge time.Duration
+		expected milestoneState
+	}{
+		"label applied in the same loop only warns": {
+			labelAge: 0,
+			expected: milestoneNeedsLabeling,
+		},
+		"label older than the floor is removed": {
+			labelAge: 2 * day,
+			expected: milestoneNeedsRemoval,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeDev)
+		// A misconfigured tiny grace period is raised to the floor.
+		m.labelGracePeriod = time.Minute
+		events := []*githubapi.IssueEvent{
+			milestoneLabelEvent(milestoneLabelsIncompleteLabel, milestoneTestBotName, time.Now().Add(-test.labelAge)),
+		}
+		obj := milestoneTestObject(incompleteLabels, m.activeMilestone, nil, events)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if icc.state != test.expected {
+			t.Errorf("%s: expected state %v, got %v", testName, test.expected, icc.state)
+		}
+	}
+}
+
+// fakeClock is a clock whose current time is controlled by the test.
+type fakeClock struct {
+	current time.Time
+}
+
+func (f *fakeClock) Now() time.Time { return f.current }
+
+func TestFakeClockGracePeriodBoundary(t *testing.T) {
+	incompleteLabels := []string{"sig/node", milestoneLabelsIncompleteLabel}
+	labelTime := time.Date(2018, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		elapsed  time.Duration
+		expected milestoneState
+	}{
+		"within the grace period only warns": {
+			elapsed:  day,
+			expected: milestoneNeedsLabeling,
+		},
+		"past the grace period is removed": {
+			elapsed:  4 * day,
+			expected: milestoneNeedsRemoval,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeDev)
+		m.clock = &fakeClock{current: labelTime.Add(test.elapsed)}
+		events := []*githubapi.IssueEvent{
+			milestoneLabelEvent(milestoneLabelsIncompleteLabel, milestoneTestBotName, labelTime),
+		}
+		obj := milestoneTestObject(incompleteLabels, m.activeMilestone, nil, events)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if icc.state != test.expected {
+			t.Errorf("%s: expected state %v, got %v", testName, test.expected, icc.state)
+		}
+	}
+}
+
+func TestMilestoneTitleNearMiss(t *testing.T) {
+	pattern := regexp.MustCompile(`^v1\.\d+(\.\d+)?$`)
+	tests := map[string]struct {
+		title    string
+		pattern  *regexp.Regexp
+		expected bool
+	}{
+		"near-miss title is flagged":                 {title: "v1.10.0", pattern: pattern, expected: true},
+		"the active milestone itself is not":         {title: "v1.10", pattern: pattern},
+		"unrelated title is not":                     {title: "next-candidate", pattern: pattern},
+		"no configured pattern disables the check":   {title: "v1.10.0"},
+		"other plausible milestones are flagged too": {title: "v1.9", pattern: pattern, expected: true},
+	}
+	for testName, test := range tests {
+		if flagged := milestoneTitleNearMiss(test.title, "v1.10", test.pattern); flagged != test.expected {
+			t.Errorf("%s: expected %t, got %t", testName, test.expected, flagged)
+		}
+	}
+}
+
+func TestHandleApprovalRequests(t *testing.T) {
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node"}
+	response := "MILESTONEAPPROVALREQUEST\n\n@kubernetes/sig-node-misc\n\n@user has requested approval"
+
+	tests := map[string]struct {
+		labels         []string
+		comments       []*githubapi.IssueComment
+		expectResponse bool
+	}{
+		"command triggers a response mentioning the sigs": {
+			labels: labels,
+			comments: []*githubapi.IssueComment{
+				issueComment(1, "user", milestoneRequestApprovalCommand, time.Now().Add(-time.Hour)),
+			},
+			expectResponse: true,
+		},
+		"no command, no response": {
+			labels: labels,
+		},
+		"approved issue is left alone": {
+			labels: append([]string{statusApprovedLabel}, labels...),
+			comments: []*githubapi.IssueComment{
+				issueComment(1, "user", milestoneRequestApprovalCommand, time.Now().Add(-time.Hour)),
+			},
+		},
+		"answered command is not answered again": {
+			labels: labels,
+			comments: []*githubapi.IssueComment{
+				issueComment(1, "user", milestoneRequestApprovalCommand, time.Now().Add(-2*time.Hour)),
+				issueComment(2, milestoneTestBotName, response, time.Now().Add(-time.Hour)),
+			},
+		},
+		"repeated command within the warning interval is rate-limited": {
+			labels: labels,
+			comments: []*githubapi.IssueComment{
+				issueComment(1, "user", milestoneRequestApprovalCommand, time.Now().Add(-3*time.Hour)),
+				issueComment(2, milestoneTestBotName, response, time.Now().Add(-2*time.Hour)),
+				issueComment(3, "user", milestoneRequestApprovalCommand, time.Now().Add(-time.Hour)),
+			},
+		},
+		"repeated command after the warning interval is answered": {
+			labels: labels,
+			comments: []*githubapi.IssueComment{
+				issueComment(1, "user", milestoneRequestApprovalCommand, time.Now().Add(-3*day)),
+				issueComment(2, milestoneTestBotName, response, time.Now().Add(-2*day)),
+				issueComment(3, "other", milestoneRequestApprovalCommand, time.Now().Add(-time.Hour)),
+			},
+			expectResponse: true,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeDev)
+		obj := milestoneTestObject(test.labels, m.activeMilestone, test.comments, nil)
+		existing := len(test.comments)
+
+		m.handleApprovalRequests(obj)
+
+		comments, _ := obj.ListComments()
+		responded := len(comments) > existing
+		if responded != test.expectResponse {
+			t.Errorf("%s: expected response=%t, got %t", testName, test.expectResponse, responded)
+		}
+		if responded {
+			body := *comments[len(comments)-1].Body
+			if !strings.Contains(body, "@kubernetes/sig-node-misc") {
+				t.Errorf("%s: expected the response to mention the sig, got:\n%s", testName, body)
+			}
+		}
+	}
+}
+
+func TestEffectiveMode(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour).Format(time.RFC3339)
+	future := now.Add(time.Hour).Format(time.RFC3339)
+
+	tests := map[string]struct {
+		mode       string
+		freezeDate string
+		expected   string
+	}{
+		"display-only freeze date leaves slush alone": {
+			mode:       milestoneModeSlush,
+			freezeDate: "Nov 22",
+			expected:   milestoneModeSlush,
+		},
+		"future freeze date leaves slush alone": {
+			mode:       milestoneModeSlush,
+			freezeDate: future,
+			expected:   milestoneModeSlush,
+		},
+		"past freeze date promotes slush to freeze": {
+			mode:       milestoneModeSlush,
+			freezeDate: past,
+			expected:   milestoneModeFreeze,
+		},
+		"freeze date at the current instant promotes slush to freeze": {
+			mode:       milestoneModeSlush,
+			freezeDate: now.Format(time.RFC3339),
+			expected:   milestoneModeFreeze,
+		},
+		"past freeze date does not promote dev": {
+			mode:       milestoneModeDev,
+			freezeDate: past,
+			expected:   milestoneModeDev,
+		},
+		"freeze stays freeze": {
+			mode:       milestoneModeFreeze,
+			freezeDate: future,
+			expected:   milestoneModeFreeze,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(test.mode)
+		m.freezeDate = test.freezeDate
+		if mode := m.effectiveMode(now); mode != test.expected {
+			t.Errorf("%s: expected mode %q, got %q", testName, test.expected, mode)
+		}
+	}
+
+	// A promoted maintainer behaves like freeze: approved non-blockers
+	// are removed rather than warned.
+	m := milestoneTestMaintainer(milestoneModeSlush)
+	m.freezeDate = past
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel}
+	icc := m.issueChangeConfig(milestoneTestObject(labels, m.activeMilestone, nil, nil))
+	if icc == nil || icc.state != milestoneNeedsRemoval {
+		t.Errorf("expected a promoted slush maintainer to remove non-blockers, got %+v", icc)
+	}
+}
+
+func TestAuditRecords(t *testing.T) {
+	// An approved non-blocker carrying a warning label is removed
+	// from the milestone during code freeze.
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel, milestoneNeedsAttentionLabel}
+
+	for _, sinkErr := range []error{nil, fmt.Errorf("sink unavailable")} {
+		m := milestoneTestMaintainer(milestoneModeFreeze)
+		sink := &recordingAuditSink{err: sinkErr}
+		m.auditSink = sink
+		obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+
+		m.Munge(obj)
+
+		// A failing sink must not block the mutations themselves.
+		if milestone, _ := obj.ReleaseMilestone(); milestone != "" {
+			t.Fatalf("expected the issue to be removed from the milestone, got %q", milestone)
+		}
+
+		expectedActions := []string{"update-state-label", "post-notification", "clear-milestone"}
+		if len(sink.records) != len(expectedActions) {
+			t.Fatalf("expected %d audit records, got %+v", len(expectedActions), sink.records)
+		}
+		for i, action := range expectedActions {
+			if sink.records[i].Action != action {
+				t.Errorf("expected audit record %d to be %q, got %q", i, action, sink.records[i].Action)
+			}
+			if sink.records[i].State != "needs-removal" || sink.records[i].Mode != milestoneModeFreeze {
+				t.Errorf("unexpected state or mode in audit record: %+v", sink.records[i])
+			}
+		}
+		if sink.records[0].NewLabel != milestoneRemovedLabel || !hasString(sink.records[0].OldLabels, milestoneNeedsAttentionLabel) {
+			t.Errorf("unexpected label transition in audit record: %+v", sink.records[0])
+		}
+	}
+}
+
+func TestUpdateIntervalForSigs(t *testing.T) {
+	freezeOverrides := map[string]time.Duration{
+		"sig/release": 12 * time.Hour,
+		"sig/docs":    3 * day,
+	}
+
+	tests := map[string]struct {
+		mode      string
+		sigLabels []string
+		expected  time.Duration
+	}{
+		"dev mode has no update interval": {
+			mode:      milestoneModeDev,
+			sigLabels: []string{"sig/release"},
+			expected:  0,
+		},
+		"no sig labels fall back to the global interval": {
+			mode:     milestoneModeFreeze,
+			expected: day,
+		},
+		"sig without an override falls back to the global interval": {
+			mode:      milestoneModeFreeze,
+			sigLabels: []string{"sig/node"},
+			expected:  day,
+		},
+		"sig override applies even when longer than the global interval": {
+			mode:      milestoneModeFreeze,
+			sigLabels: []string{"sig/docs"},
+			expected:  3 * day,
+		},
+		"overlapping overrides select the shortest interval": {
+			mode:      milestoneModeFreeze,
+			sigLabels: []string{"sig/docs", "sig/release"},
+			expected:  12 * time.Hour,
+		},
+		"slush mode ignores freeze overrides": {
+			mode:      milestoneModeSlush,
+			sigLabels: []string{"sig/release"},
+			expected:  3 * day,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(test.mode)
+		m.sigFreezeIntervals = freezeOverrides
+		if interval := m.updateIntervalForSigs(test.mode, test.sigLabels); interval != test.expected {
+			t.Errorf("%s: expected interval %v, got %v", testName, test.expected, interval)
+		}
+	}
+}
+
+func TestParseSigUpdateIntervals(t *testing.T) {
+	intervals, err := parseSigUpdateIntervals("sig/node=24h, sig/docs=72h")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(intervals) != 2 || intervals["sig/node"] != day || intervals["sig/docs"] != 3*day {
+		t.Errorf("unexpected intervals: %v", intervals)
+	}
+	for _, spec := range []string{"sig/node", "node=24h", "sig/node=soon"} {
+		if _, err := parseSigUpdateIntervals(spec); err == nil {
+			t.Errorf("expected an error for spec %q", spec)
+		}
+	}
+}
+
+func TestParseRemovalBlackout(t *testing.T) {
+	tests := map[string]struct {
+		start       string
+		end         string
+		expectError bool
+		expectZero  bool
+	}{
+		"empty specs disable the blackout": {expectZero: true},
+		"valid window":                     {start: "2018-12-20", end: "2019-01-02"},
+		"single day window":                {start: "2018-12-25", end: "2018-12-25"},
+		"missing end date":                 {start: "2018-12-20", expectError: true},
+		"missing start date":               {end: "2019-01-02", expectError: true},
+		"unparseable date":                 {start: "Dec 20", end: "2019-01-02", expectError: true},
+		"end before start":                 {start: "2019-01-02", end: "2018-12-20", expectError: true},
+	}
+	for testName, test := range tests {
+		start, end, err := parseRemovalBlackout(test.start, test.end)
+		if test.expectError {
+			if err == nil {
+				t.Errorf("%s: expected an error", testName)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", testName, err)
+			continue
+		}
+		if test.expectZero {
+			if !start.IsZero() || !end.IsZero() {
+				t.Errorf("%s: expected a zero window, got [%v, %v)", testName, start, end)
+			}
+			continue
+		}
+		// The end date is inclusive, so even a single day window
+		// must span a full day.
+		if end.Sub(start) < day {
+			t.Errorf("%s: expected the window to span at least a day, got [%v, %v)", testName, start, end)
+		}
+	}
+}
+
+func TestRemovalBlackout(t *testing.T) {
+	approvedLabels := []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel}
+	unapprovedLabels := []string{"kind/bug", "priority/important-soon", "sig/node"}
+
+	tests := map[string]struct {
+		mode          string
+		labels        []string
+		blackoutStart time.Duration // offset from now
+		blackoutEnd   time.Duration // offset from now
+		pausesGrace   bool
+		graceLabel    string
+		graceLabelAge time.Duration
+		expected      milestoneState
+	}{
+		"non-blocker is removed during freeze outside the blackout": {
+			mode:          milestoneModeFreeze,
+			labels:        approvedLabels,
+			blackoutStart: -10 * day,
+			blackoutEnd:   -5 * day,
+			expected:      milestoneNeedsRemoval,
+		},
+		"non-blocker is only warned during freeze inside the blackout": {
+			mode:          milestoneModeFreeze,
+			labels:        approvedLabels,
+			blackoutStart: -day,
+			blackoutEnd:   2 * day,
+			expected:      milestoneNeedsAttention,
+		},
+		"expired approval grace warns instead of removing inside the blackout": {
+			mode:          milestoneModeDev,
+			labels:        unapprovedLabels,
+			blackoutStart: -day,
+			blackoutEnd:   2 * day,
+			graceLabel:    milestoneNeedsApprovalLabel,
+			graceLabelAge: 8 * day,
+			expected:      milestoneNeedsApproval,
+		},
+		"expired approval grace removes after the blackout without pausing": {
+			mode:          milestoneModeDev,
+			labels:        unapprovedLabels,
+			blackoutStart: -6 * day,
+			blackoutEnd:   -2 * day,
+			graceLabel:    milestoneNeedsApprovalLabel,
+			graceLabelAge: 8 * day,
+			expected:      milestoneNeedsRemoval,
+		},
+		"paused grace clock extends the grace period by the blackout": {
+			mode:          milestoneModeDev,
+			labels:        unapprovedLabels,
+			blackoutStart: -6 * day,
+			blackoutEnd:   -2 * day,
+			pausesGrace:   true,
+			graceLabel:    milestoneNeedsApprovalLabel,
+			graceLabelAge: 8 * day,
+			expected:      milestoneNeedsApproval,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(test.mode)
+		now := time.Now()
+		m.removalBlackoutStart = now.Add(test.blackoutStart)
+		m.removalBlackoutEnd = now.Add(test.blackoutEnd)
+		m.blackoutPausesGrace = test.pausesGrace
+		labels := test.labels
+		var events []*githubapi.IssueEvent
+		if test.graceLabel != "" {
+			labels = append([]string{test.graceLabel}, labels...)
+			events = append(events, milestoneLabelEvent(test.graceLabel, milestoneTestBotName, now.Add(-test.graceLabelAge)))
+		}
+		obj := milestoneTestObject(labels, m.activeMilestone, nil, events)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if icc.state != test.expected {
+			t.Errorf("%s: expected state %v, got %v", testName, test.expected, icc.state)
+		}
+	}
+}
+
+func TestMungeEditsNotificationInPlace(t *testing.T) {
+	staleNotification := "MILESTONENOTIFIER Milestone Labels **Incomplete**\n\nstale context"
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node"}
+
+	tests := map[string]struct {
+		editNotifications bool
+		existingComment   bool
+		expectEdited      bool
+	}{
+		"changed notification is edited in place": {
+			editNotifications: true,
+			existingComment:   true,
+			expectEdited:      true,
+		},
+		"changed notification is recreated when editing is disabled": {
+			existingComment: true,
+		},
+		"missing notification is posted even when editing is enabled": {
+			editNotifications: true,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeDev)
+		m.editNotifications = test.editNotifications
+		var comments []*githubapi.IssueComment
+		if test.existingComment {
+			comments = []*githubapi.IssueComment{
+				issueComment(27, milestoneTestBotName, staleNotification, time.Now().Add(-time.Hour)),
+			}
+		}
+		obj := milestoneTestObject(labels, m.activeMilestone, comments, nil)
+
+		m.Munge(obj)
+
+		comment, ok := latestNotificationComment(obj, []string{milestoneTestBotName})
+		if !ok || comment == nil {
+			t.Fatalf("%s: expected a notification comment", testName)
+		}
+		notification := c.ParseNotification(comment)
+		if notification == nil || notification.Arguments != "Milestone Issue **Needs Approval**" {
+			t.Fatalf("%s: expected a needs-approval notification, got %v", testName, notification)
+		}
+		source := comment.Source.(*githubapi.IssueComment)
+		edited := source.ID != nil && *source.ID == 27
+		if edited != test.expectEdited {
+			t.Errorf("%s: expected edited=%t, got %t", testName, test.expectEdited, edited)
+		}
+	}
+}
+
+func TestNotificationFooterFromConfig(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeDev)
+	m.helpBaseURL = "https://github.example.com/myorg/community/blob/master"
+	m.sigMentionTemplate = "@myorg/sig-%s"
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node"}
+	obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+
+	change := m.issueChange(obj)
+	if change == nil {
+		t.Fatalf("expected an issue change")
+	}
+	context := change.notification.Context
+	if !strings.Contains(context, "https://github.example.com/myorg/community/blob/master/contributors/devel/release/issues.md") {
+		t.Errorf("expected footer links rooted at the configured base url, got:\n%s", context)
+	}
+	if strings.Contains(context, defaultHelpBaseURL) {
+		t.Errorf("expected the default base url to be absent, got:\n%s", context)
+	}
+	if !strings.Contains(context, "@myorg/sig-node") {
+		t.Errorf("expected the configured sig mention template to be used, got:\n%s", context)
+	}
+}
+
+func TestUnapprovedMessageNamesSigs(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeDev)
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node", "sig/storage"}
+	obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+
+	icc := m.issueChangeConfig(obj)
+	if icc == nil {
+		t.Fatalf("expected an issue change config")
+	}
+	if icc.state != milestoneNeedsApproval {
+		t.Fatalf("expected state %v, got %v", milestoneNeedsApproval, icc.state)
+	}
+	messageBody := icc.messageBody()
+	if messageBody == nil {
+		t.Fatalf("expected a message body")
+	}
+	for _, expected := range []string{"sig/node", "sig/storage", "@kubernetes/sig-node-misc", "@kubernetes/sig-storage-misc"} {
+		if !strings.Contains(*messageBody, expected) {
+			t.Errorf("expected message to contain %q, got:\n%s", expected, *messageBody)
+		}
+	}
+}
+
+func TestTemplatedNotificationTitle(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeFreeze)
+	labels := []string{"kind/bug", "priority/critical-urgent", "sig/node", statusApprovedLabel, statusInProgressLabel}
+	obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+
+	icc := m.issueChangeConfig(obj)
+	if icc == nil {
+		t.Fatalf("expected an issue change config")
+	}
+
+	title := expandNotificationTitle("Milestone Issue **Current** — {{.milestone}} [{{.mode}}]", icc.templateArguments)
+	expected := "Milestone Issue **Current** — v1.10 milestone [freeze]"
+	if title != expected {
+		t.Fatalf("expected title %q, got %q", expected, title)
+	}
+
+	// A plain title passes through unchanged.
+	if plain := expandNotificationTitle("Milestone Issue **Current**", icc.templateArguments); plain != "Milestone Issue **Current**" {
+		t.Errorf("expected plain title to pass through, got %q", plain)
+	}
+
+	// The interpolated title must round-trip through the notification
+	// equality check used to decide whether to repost.
+	notification := c.NewNotification(milestoneNotifierName, title, "context")
+	ic := issueComment(1, milestoneTestBotName, notification.String(), time.Now())
+	if !notificationIsCurrent(notification, c.FromIssueComment(ic), nil, time.Now()) {
+		t.Errorf("expected interpolated title to round-trip through notificationIsCurrent")
+	}
+}
+
+func TestNotificationIsCurrent(t *testing.T) {
+	interval := day
+	tests := map[string]struct {
+		commentBody     string
+		commentAge      time.Duration
+		commentInterval *time.Duration
+		expected        bool
+	}{
+		"missing comment is not current": {
+			expected: false,
+		},
+		"differing notification is not current": {
+			commentBody: "MILESTONENOTIFIER Something Else",
+			expected:    false,
+		},
+		"matching notification without interval is current": {
+			commentBody: "MILESTONENOTIFIER Milestone Issue **Current**\n\ncontext",
+			expected:    true,
+		},
+		"matching notification within interval is current": {
+			commentBody:     "MILESTONENOTIFIER Milestone Issue **Current**\n\ncontext",
+			commentInterval: &interval,
+			expected:        true,
+		},
+		"matching notification past interval is not current": {
+			commentBody:     "MILESTONENOTIFIER Milestone Issue **Current**\n\ncontext",
+			commentAge:      2 * day,
+			commentInterval: &interval,
+			expected:        false,
+		},
+	}
+	for testName, test := range tests {
+		notification := c.NewNotification(milestoneNotifierName, "Milestone Issue **Current**", "context")
+		var comment *c.Comment
+		if test.commentBody != "" {
+			ic := issueComment(1, milestoneTestBotName, test.commentBody, time.Now().Add(-test.commentAge))
+			comment = c.FromIssueComment(ic)
+		}
+		if current := notificationIsCurrent(notification, comment, test.commentInterval, time.Now()); current != test.expected {
+			t.Errorf("%s: expected %t, got %t", testName, test.expected, current)
+		}
+	}
+}
+
+func TestEachLoopVerifiesActiveMilestone(t *testing.T) {
+	tests := map[string]struct {
+		milestones  string
+		expectError bool
+	}{
+		"a missing milestone fails the loop": {milestones: "[]", expectError: true},
+		"an existing milestone passes":       {milestones: `[{"number": 1, "title": "v1.10"}]`, expectError: false},
+		"an api error fails the loop":        {milestones: "", expectError: true},
+	}
+	for testName, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if test.milestones == "" {
+				http.Error(w, "boom", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, test.milestones)
+		}))
+
+		m := milestoneTestMaintainer(milestoneModeDev)
+		m.config = &github.Config{Org: "org", Project: "project", Endpoint: server.URL}
+		if err := m.config.PreExecute(); err != nil {
+			t.Fatalf("%s: unable to initialize config: %v", testName, err)
+		}
+
+		err := m.EachLoop()
+		if test.expectError && err == nil {
+			t.Errorf("%s: expected an error, got none", testName)
+		}
+		if !test.expectError && err != nil {
+			t.Errorf("%s: unexpected error: %v", testName, err)
+		}
+		server.Close()
+	}
+}
+
+func TestApplyStatusCommands(t *testing.T) {
+	tests := map[string]struct {
+		author   string
+		expected bool
+	}{
+		"sig member can mark an issue in progress":    {author: "sig-member", expected: true},
+		"non-member cannot mark an issue in progress": {author: "passer-by", expected: false},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeFreeze)
+		m.features = features.NewFeatures()
+		m.features.SigMembership.AddMembers("node", "sig-member")
+
+		comments := []*githubapi.IssueComment{
+			issueComment(1, test.author, milestoneInProgressCommand, time.Now()),
+		}
+		obj := milestoneTestObject([]string{"sig/node"}, m.activeMilestone, comments, nil)
+
+		m.applyStatusCommands(obj)
+
+		if obj.HasLabel(statusInProgressLabel) != test.expected {
+			t.Errorf("%s: expected HasLabel(%q) to be %t", testName, statusInProgressLabel, test.expected)
+		}
+	}
+}
+
+func TestApplyApprovalCommands(t *testing.T) {
+	tests := map[string]struct {
+		author   string
+		expected bool
+	}{
+		"sig member can approve for the milestone": {author: "sig-member", expected: true},
+		"non-member cannot approve":                {author: "passer-by", expected: false},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeFreeze)
+		m.features = features.NewFeatures()
+		m.features.SigMembership.AddMembers("node", "sig-member")
+
+		comments := []*githubapi.IssueComment{
+			issueComment(1, test.author, milestoneApproveCommand, time.Now()),
+		}
+		obj := milestoneTestObject([]string{"sig/node"}, m.activeMilestone, comments, nil)
+
+		m.applyApprovalCommands(obj)
+
+		if obj.HasLabel(statusApprovedLabel) != test.expected {
+			t.Errorf("%s: expected HasLabel(%q) to be %t", testName, statusApprovedLabel, test.expected)
+		}
+	}
+}
+
+func TestBackoffInterval(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeSlush)
+	m.warningBackoff = true
+	m.warningBackoffCap = 4 * day
+
+	tests := map[string]struct {
+		commentBody string
+		expected    time.Duration
+	}{
+		"no previous notification": {
+			expected: day,
+		},
+		"legacy notification without a marker": {
+			commentBody: "MILESTONENOTIFIER Milestone Issue **Needs Attention**\n\ncontext",
+			expected:    day,
+		},
+		"first repeat doubles the interval": {
+			commentBody: "MILESTONENOTIFIER Milestone Issue **Needs Attention**\n\ncontext\n<!-- warning-repeat: 1 -->",
+			expected:    2 * day,
+		},
+		"second repeat doubles again": {
+			commentBody: "MILESTONENOTIFIER Milestone Issue **Needs Attention**\n\ncontext\n<!-- warning-repeat: 2 -->",
+			expected:    4 * day,
+		},
+		"the interval does not grow past the cap": {
+			commentBody: "MILESTONENOTIFIER Milestone Issue **Needs Attention**\n\ncontext\n<!-- warning-repeat: 5 -->",
+			expected:    4 * day,
+		},
+	}
+	for testName, test := range tests {
+		var comment *c.Comment
+		if test.commentBody != "" {
+			comment = c.FromIssueComment(issueComment(1, milestoneTestBotName, test.commentBody, time.Now()))
+		}
+		if interval := m.backoffInterval(comment); interval != test.expected {
+			t.Errorf("%s: expected interval %v, got %v", testName, test.expected, interval)
+		}
+	}
+}
+
+func TestRetryMutation(t *testing.T) {
+	transient := fmt.Errorf("502 bad gateway")
+	retryAfter := time.Minute
+	abuse := &githubapi.AbuseRateLimitError{RetryAfter: &retryAfter}
+
+	tests := map[string]struct {
+		retries       int
+		failures      int
+		failWith      error
+		expectedErr   bool
+		expectedWaits []time.Duration
+	}{
+		"immediate success does not wait": {
+			retries: 2,
+		},
+		"transient failure then success": {
+			retries:       2,
+			failures:      2,
+			failWith:      transient,
+			expectedWaits: []time.Duration{time.Second, 2 * time.Second},
+		},
+		"persistent failure gives up after the configured retries": {
+			retries:       1,
+			failures:      5,
+			failWith:      transient,
+			expectedErr:   true,
+			expectedWaits: []time.Duration{time.Second},
+		},
+		"rate limit waits for the server-provided interval": {
+			retries:       1,
+			failures:      1,
+			failWith:      abuse,
+			expectedWaits: []time.Duration{time.Minute},
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeDev)
+		m.mutationRetries = test.retries
+		m.mutationRetryBackoff = time.Second
+		waits := []time.Duration{}
+		m.sleep = func(duration time.Duration) {
+			waits = append(waits, duration)
+		}
+		remaining := test.failures
+		err := m.retryMutation("testing", func() error {
+			if remaining > 0 {
+				remaining--
+				return test.failWith
+			}
+			return nil
+		})
+		if (err != nil) != test.expectedErr {
+			t.Errorf("%s: expected error %t, got %v", testName, test.expectedErr, err)
+		}
+		if !reflect.DeepEqual(waits, test.expectedWaits) && len(waits)+len(test.expectedWaits) > 0 {
+			t.Errorf("%s: expected waits %v, got %v", testName, test.expectedWaits, waits)
+		}
+	}
+}
+
+func TestNotificationsMatchIgnoresRepeatMarker(t *testing.T) {
+	notification := c.NewNotification(milestoneNotifierName, "Milestone Issue **Needs Attention**", "context")
+	comment := c.FromIssueComment(issueComment(1, milestoneTestBotName,
+		"MILESTONENOTIFIER Milestone Issue **Needs Attention**\n\ncontext\n<!-- warning-repeat: 2 -->", time.Now()))
+	if !notificationsMatch(notification, comment) {
+		t.Errorf("expected notifications to match ignoring the repeat marker")
+	}
+}
+
+func TestUpdateMilestoneStateLabel(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeDev)
+	obj := milestoneTestObject([]string{milestoneNeedsApprovalLabel}, "v1.10", nil, nil)
+	if !m.updateMilestoneStateLabel(obj, milestoneNeedsAttentionLabel) {
+		t.Fatalf("expected label update to succeed")
+	}
+	if !obj.HasLabel(milestoneNeedsAttentionLabel) {
+		t.Errorf("expected %q to be applied", milestoneNeedsAttentionLabel)
+	}
+	if obj.HasLabel(milestoneNeedsApprovalLabel) {
+		t.Errorf("expected %q to be removed", milestoneNeedsApprovalLabel)
+	}
+}
+
+func TestDurationToMaxDays(t *testing.T) {
+	tests := map[time.Duration]string{
+		day:              "1 day",
+		3 * day:          "3 days",
+		day + time.Hour:  "2 days",
+		30 * time.Minute: "1 day",
+	}
+	for duration, expected := range tests {
+		if actual := durationToMaxDays(duration); actual != expected {
+			t.Errorf("expected %q for %v, got %q", expected, duration, actual)
+		}
+	}
+}
+
+func TestDurationToDaysAndHours(t *testing.T) {
+	tests := map[time.Duration]string{
+		12 * time.Hour: "12 hours",
+		36 * time.Hour: "1 day 12 hours",
+		72 * time.Hour: "3 days",
+		time.Hour:      "1 hour",
+		25 * time.Hour: "1 day 1 hour",
+	}
+	for duration, expected := range tests {
+		if actual := durationToDaysAndHours(duration); actual != expected {
+			t.Errorf("expected %q for %v, got %q", expected, duration, actual)
+		}
+	}
+}
+
+func issueComment(id int64, login, body string, createdAt time.Time) *githubapi.IssueComment {
+	return &githubapi.IssueComment{
+		ID:        &id,
+		User:      &githubapi.User{Login: &login},
+		Body:      &body,
+		CreatedAt: &createdAt,
+	}
+}
+
+func milestoneLabelEvent(label, actor string, createdAt time.Time) *githubapi.IssueEvent {
+	labeled := "labeled"
+	return &githubapi.IssueEvent{
+		Event:     &labeled,
+		Label:     &githubapi.Label{Name: &label},
+		Actor:     &githubapi.User{Login: &actor},
+		CreatedAt: &createdAt,
+	}
+}
+
+func TestNeedsTriage(t *testing.T) {
+	tests := map[string]struct {
+		labels        []string
+		expectedLabel string
+	}{
+		"zero recognized labels needs triage": {
+			labels:        []string{},
+			expectedLabel: milestoneNeedsTriageLabel,
+		},
+		"unrecognized labels still need triage": {
+			labels:        []string{"area/kubelet"},
+			expectedLabel: milestoneNeedsTriageLabel,
+		},
+		"partially labeled issue is incomplete, not untriaged": {
+			labels:        []string{"sig/node"},
+			expectedLabel: milestoneLabelsIncompleteLabel,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeDev)
+		obj := milestoneTestObject(test.labels, m.activeMilestone, nil, nil)
+
+		m.Munge(obj)
+
+		if !obj.HasLabel(test.expectedLabel) {
+			t.Errorf("%s: expected %q to be applied", testName, test.expectedLabel)
+		}
+		if test.expectedLabel == milestoneNeedsTriageLabel {
+			comment, ok := latestNotificationComment(obj, []string{milestoneTestBotName})
+			if !ok || comment == nil || comment.Body == nil {
+				t.Errorf("%s: expected a notification comment", testName)
+				continue
+			}
+			if !strings.Contains(*comment.Body, "needs triage") {
+				t.Errorf("%s: expected triage message, got %q", testName, *comment.Body)
+			}
+		}
+	}
+}
+
+func TestUpdateRequiredLinksLastUpdate(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeSlush)
+	labels := []string{"kind/bug", blockerLabel, "sig/node", statusApprovedLabel, statusInProgressLabel}
+	commentURL := "https://github.com/org/project/issues/1#issuecomment-7"
+	comment := issueComment(7, "user", "working on it", time.Now().Add(-4*day))
+	comment.HTMLURL = &commentURL
+	obj := milestoneTestObject(labels, m.activeMilestone, []*githubapi.IssueComment{comment}, nil)
+
+	icc := m.issueChangeConfig(obj)
+	if icc == nil {
+		t.Fatalf("expected an issue change config")
+	}
+	if !icc.enabledSections.Has("warnUpdateRequired") {
+		t.Fatalf("expected warnUpdateRequired to be enabled")
+	}
+	lastUpdated, ok := icc.templateArguments["lastUpdated"].(string)
+	if !ok {
+		t.Fatalf("expected lastUpdated to be a string, got %v", icc.templateArguments["lastUpdated"])
+	}
+	if !strings.Contains(lastUpdated, commentURL) {
+		t.Errorf("expected lastUpdated to link to the comment, got %q", lastUpdated)
+	}
+
+	// A last update without a url (e.g. issue creation) falls back to
+	// the bare date.
+	bare := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+	icc = m.issueChangeConfig(bare)
+	if icc == nil {
+		t.Fatalf("expected an issue change config")
+	}
+	lastUpdated, _ = icc.templateArguments["lastUpdated"].(string)
+	if strings.Contains(lastUpdated, "](") {
+		t.Errorf("expected no link for an update without a url, got %q", lastUpdated)
+	}
+}
+
+func TestCleanupClosed(t *testing.T) {
+	tests := map[string]struct {
+		cleanupClosed   bool
+		expectedRemoved bool
+	}{
+		"closed issues are untouched by default": {},
+		"cleanup strips state labels from closed issues": {
+			cleanupClosed:   true,
+			expectedRemoved: true,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeDev)
+		m.cleanupClosed = test.cleanupClosed
+		labels := []string{"sig/node", milestoneLabelsIncompleteLabel}
+		obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+		closed := "closed"
+		obj.Issue.State = &closed
+
+		m.Munge(obj)
+
+		if obj.HasLabel(milestoneLabelsIncompleteLabel) == test.expectedRemoved {
+			t.Errorf("%s: expected label removal=%t", testName, test.expectedRemoved)
+		}
+		if comment, ok := latestNotificationComment(obj, []string{milestoneTestBotName}); !ok || comment != nil {
+			t.Errorf("%s: expected no notification comment on a closed issue", testName)
+		}
+	}
+}
+
+func TestWarningCountInterpolation(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeDev)
+	// A zero interval reposts the warning on every munge.
+	m.warningInterval = 0
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node"}
+	obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+
+	body := func() string {
+		comment, ok := latestNotificationComment(obj, []string{milestoneTestBotName})
+		if !ok || comment == nil || comment.Body == nil {
+			t.Fatalf("expected a notification comment")
+		}
+		return *comment.Body
+	}
+
+	m.Munge(obj)
+	if strings.Contains(body(), "This is reminder") {
+		t.Errorf("expected no reminder ordinal on the first warning, got %q", body())
+	}
+
+	m.Munge(obj)
+	if !strings.Contains(body(), "This is reminder #2.") {
+		t.Errorf("expected the second warning to carry ordinal 2, got %q", body())
+	}
+
+	m.Munge(obj)
+	if !strings.Contains(body(), "This is reminder #3.") {
+		t.Errorf("expected the third warning to carry ordinal 3, got %q", body())
+	}
+}
+
+func TestLabelLastCreatedAtPaginated(t *testing.T) {
+	earlier := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	eventJSON := func(id int64, createdAt time.Time) string {
+		return fmt.Sprintf(`{"id": %d, "event": "labeled", "label": {"name": %q}, "actor": {"login": %q}, "created_at": %q}`,
+			id, milestoneLabelsIncompleteLabel, milestoneTestBotName, createdAt.Format(time.RFC3339))
+	}
+	mux.HandleFunc("/repos/org/project/issues/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"number": 1, "state": "open", "created_at": "2018-01-01T00:00:00Z"}`)
+	})
+	mux.HandleFunc("/repos/org/project/issues/1/events", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprintf(w, "[%s]", eventJSON(2, later))
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s/repos/org/project/issues/1/events?page=2>; rel="next", <%s/repos/org/project/issues/1/events?page=2>; rel="last"`, server.URL, server.URL))
+		fmt.Fprintf(w, "[%s]", eventJSON(1, earlier))
+	})
+
+	config := &github.Config{Org: "org", Project: "project", BotName: milestoneTestBotName, Endpoint: server.URL, DryRun: true}
+	if err := config.PreExecute(); err != nil {
+		t.Fatalf("failed to initialize client: %v", err)
+	}
+	obj, err := config.NewObject(1)
+	if err != nil {
+		t.Fatalf("failed to fetch issue: %v", err)
+	}
+
+	lastAdded, ok := labelLastCreatedAt(obj, []string{milestoneTestBotName}, milestoneLabelsIncompleteLabel)
+	if !ok || lastAdded == nil {
+		t.Fatalf("expected the label event to be found")
+	}
+	if !lastAdded.Equal(later) {
+		t.Errorf("expected the last label event from the second page, got %v", lastAdded)
+	}
+}
+
+func TestRequireBlockerApproval(t *testing.T) {
+	tests := map[string]struct {
+		requireApproval bool
+		gracePeriod     time.Duration
+		expected        milestoneState
+	}{
+		"blockers are warned but never removed by default": {
+			expected: milestoneNeedsApproval,
+		},
+		"a zero grace period warns indefinitely": {
+			requireApproval: true,
+			expected:        milestoneNeedsApproval,
+		},
+		"an expired grace period removes the blocker": {
+			requireApproval: true,
+			gracePeriod:     3 * day,
+			expected:        milestoneNeedsRemoval,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeDev)
+		m.requireBlockerApproval = test.requireApproval
+		m.blockerApprovalGracePeriod = test.gracePeriod
+		labels := []string{"kind/bug", blockerLabel, "sig/node", milestoneNeedsApprovalLabel}
+		events := []*githubapi.IssueEvent{
+			milestoneLabelEvent(milestoneNeedsApprovalLabel, milestoneTestBotName, time.Now().Add(-4*day)),
+		}
+		obj := milestoneTestObject(labels, m.activeMilestone, nil, events)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if icc.state != test.expected {
+			t.Errorf("%s: expected state %v, got %v", testName, test.expected, icc.state)
+		}
+	}
+}
+
+func TestRemovalReasonCodes(t *testing.T) {
+	tests := map[string]struct {
+		mode           string
+		labels         []string
+		expectedReason string
+	}{
+		"unapproved removal": {
+			mode:           milestoneModeDev,
+			labels:         []string{"kind/bug", "priority/important-soon", "sig/node", milestoneNeedsApprovalLabel},
+			expectedReason: milestoneRemovalReasonUnapproved,
+		},
+		"incomplete-labels removal": {
+			mode:           milestoneModeDev,
+			labels:         []string{"sig/node", milestoneLabelsIncompleteLabel},
+			expectedReason: milestoneRemovalReasonIncompleteLabels,
+		},
+		"non-blocker freeze removal": {
+			mode:           milestoneModeFreeze,
+			labels:         []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel},
+			expectedReason: milestoneRemovalReasonNonBlocker,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(test.mode)
+		// An old label event expires the grace period for the
+		// removal-by-grace paths.
+		events := []*githubapi.IssueEvent{
+			milestoneLabelEvent(milestoneNeedsApprovalLabel, milestoneTestBotName, time.Now().Add(-30*day)),
+			milestoneLabelEvent(milestoneLabelsIncompleteLabel, milestoneTestBotName, time.Now().Add(-30*day)),
+		}
+		obj := milestoneTestObject(test.labels, m.activeMilestone, nil, events)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if icc.state != milestoneNeedsRemoval {
+			t.Fatalf("%s: expected state %v, got %v", testName, milestoneNeedsRemoval, icc.state)
+		}
+		body := icc.messageBody()
+		if body == nil {
+			t.Fatalf("%s: expected a message body", testName)
+		}
+		expectedMarker := fmt.Sprintf(milestoneRemovalReasonMarker, test.expectedReason)
+		if !strings.Contains(*body, expectedMarker) {
+			t.Errorf("%s: expected marker %q in %q", testName, expectedMarker, *body)
+		}
+	}
+}
+
+func TestLegacyBotNames(t *testing.T) {
+	const legacyBotName = "old-test-bot"
+
+	m := milestoneTestMaintainer(milestoneModeDev)
+	m.legacyBotNames = []string{legacyBotName}
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node", milestoneNeedsApprovalLabel}
+	// The needs-approval label was applied by the legacy bot before
+	// the migration; the grace start must survive the identity change.
+	events := []*githubapi.IssueEvent{
+		milestoneLabelEvent(milestoneNeedsApprovalLabel, legacyBotName, time.Now().Add(-30*day)),
+	}
+	obj := milestoneTestObject(labels, m.activeMilestone, nil, events)
+
+	icc := m.issueChangeConfig(obj)
+	if icc == nil {
+		t.Fatalf("expected an issue change config")
+	}
+	if icc.state != milestoneNeedsRemoval {
+		t.Errorf("expected the legacy grace start to be honored, got state %v", icc.state)
+	}
+
+	// Without the legacy name registered the event is unrecognized and
+	// the grace period silently restarts.
+	m.legacyBotNames = nil
+	icc = m.issueChangeConfig(obj)
+	if icc == nil {
+		t.Fatalf("expected an issue change config")
+	}
+	if icc.state != milestoneNeedsApproval {
+		t.Errorf("expected the grace period to restart without the legacy name, got state %v", icc.state)
+	}
+}
+
+func TestRepoMilestones(t *testing.T) {
+	repoMilestones, err := parseRepoMilestones("org/project=v1.10, other/repo=v2.3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repoMilestones) != 2 || repoMilestones["org/project"] != "v1.10" || repoMilestones["other/repo"] != "v2.3" {
+		t.Fatalf("unexpected parse result: %v", repoMilestones)
+	}
+	for _, spec := range []string{"org/project", "org=v1.10", "org/project="} {
+		if _, err := parseRepoMilestones(spec); err == nil {
+			t.Errorf("expected an error for spec %q", spec)
+		}
+	}
+
+	m := milestoneTestMaintainer(milestoneModeFreeze)
+	m.repoMilestones = repoMilestones
+
+	repoObject := func(org, project, milestone string) *github.MungeObject {
+		issue := github_test.Issue("user", 1, []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel}, false)
+		issue.Milestone = &githubapi.Milestone{Title: &milestone}
+		config := &github.Config{Org: org, Project: project, BotName: milestoneTestBotName, DryRun: true}
+		return github.NewTestObject(config, issue, nil, nil)
+	}
+
+	// Each issue is judged against its own repo's active milestone.
+	first := repoObject("org", "project", "v1.10")
+	second := repoObject("other", "repo", "v2.3")
+	mismatched := repoObject("other", "repo", "v1.10")
+
+	m.Munge(first)
+	m.Munge(second)
+	m.Munge(mismatched)
+
+	if !first.HasLabel(milestoneRemovedLabel) {
+		t.Errorf("expected the first repo's issue to be processed")
+	}
+	if !second.HasLabel(milestoneRemovedLabel) {
+		t.Errorf("expected the second repo's issue to be processed")
+	}
+	if mismatched.HasLabel(milestoneRemovedLabel) {
+		t.Errorf("expected the mismatched milestone to be ignored")
+	}
+}
+
+func TestTemplateOverrides(t *testing.T) {
+	dir, err := ioutil.TempDir("", "milestone-templates")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	writeTemplate := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return path
+	}
+
+	m := milestoneTestMaintainer(milestoneModeDev)
+
+	// A broken override fails validation at load time.
+	m.messageTemplateFile = writeTemplate("broken.tmpl", "{{.unclosed")
+	if err := m.loadTemplateOverrides(); err == nil {
+		t.Errorf("expected a broken message template to fail validation")
+	}
+
+	// A valid override replaces the built-in template.
+	m.messageTemplateFile = writeTemplate("custom.tmpl", "{{if .warnUnapproved}}Custom approval wording.{{end}}")
+	if err := m.loadTemplateOverrides(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node"}
+	obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+	icc := m.issueChangeConfig(obj)
+	if icc == nil {
+		t.Fatalf("expected an issue change config")
+	}
+	body := icc.messageBody()
+	if body == nil || !strings.Contains(*body, "Custom approval wording.") {
+		t.Errorf("expected the override template to render, got %v", body)
+	}
+
+	// Clearing the path falls back to the built-in template.
+	m.messageTemplateFile = ""
+	if err := m.loadTemplateOverrides(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	icc = m.issueChangeConfig(obj)
+	if body := icc.messageBody(); body == nil || !strings.Contains(*body, "**Heads-up**") {
+		t.Errorf("expected the built-in template to render, got %v", body)
+	}
+
+	// The detail block override is appended to notifications.
+	m.detailTemplateFile = writeTemplate("detail.tmpl", "Custom help rooted at %s (also %s).")
+	if err := m.loadTemplateOverrides(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if detail := m.detailSection(nil); !strings.Contains(detail, "Custom help rooted at") {
+		t.Errorf("expected the detail override to render, got %q", detail)
+	}
+}
+
+func TestSigHelpURLs(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeDev)
+	m.sigHelpURLs = map[string]string{"sig/node": "https://example.com/node-milestones"}
+
+	detail := m.detailSection([]string{"sig/node", "sig/storage"})
+	if !strings.Contains(detail, "https://example.com/node-milestones") {
+		t.Errorf("expected the sig-specific link, got %q", detail)
+	}
+	if strings.Index(detail, "node-milestones") > strings.Index(detail, "</ul>") {
+		t.Errorf("expected the sig-specific link inside the list, got %q", detail)
+	}
+
+	// An issue without a configured sig only gets the generic links.
+	if detail := m.detailSection([]string{"sig/storage"}); strings.Contains(detail, "node-milestones") {
+		t.Errorf("expected no sig-specific link, got %q", detail)
+	}
+
+	if _, err := parseSigHelpURLs("node=https://example.com"); err == nil {
+		t.Errorf("expected an error for a spec without a sig label prefix")
+	}
+}
+
+func TestSnooze(t *testing.T) {
+	now := time.Date(2018, time.May, 15, 12, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		snoozeLabel    string
+		expectedMunged bool
+	}{
+		"an active snooze suspends munging": {
+			snoozeLabel: defaultSnoozeLabelPrefix + "2018-06-01",
+		},
+		"an expired snooze resumes munging": {
+			snoozeLabel:    defaultSnoozeLabelPrefix + "2018-05-01",
+			expectedMunged: true,
+		},
+		"a malformed snooze is ignored": {
+			snoozeLabel:    defaultSnoozeLabelPrefix + "someday",
+			expectedMunged: true,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeDev)
+		m.clock = &fakeClock{current: now}
+		labels := []string{"kind/bug", "priority/important-soon", "sig/node", test.snoozeLabel}
+		obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+
+		m.Munge(obj)
+
+		if obj.HasLabel(milestoneNeedsApprovalLabel) != test.expectedMunged {
+			t.Errorf("%s: expected munged=%t", testName, test.expectedMunged)
+		}
+		comment, ok := latestNotificationComment(obj, []string{milestoneTestBotName})
+		if !ok {
+			t.Fatalf("%s: failed to list comments", testName)
+		}
+		if (comment != nil) != test.expectedMunged {
+			t.Errorf("%s: expected notification=%t", testName, test.expectedMunged)
+		}
+	}
+}
+
+func TestFirstContactWording(t *testing.T) {
+	// Comments are stamped with the wall clock even in dry-run, so
+	// base the fake clock on it to keep comment ages consistent.
+	start := time.Now()
+	m := milestoneTestMaintainer(milestoneModeDev)
+	m.warningInterval = day
+	clock := &fakeClock{current: start}
+	m.clock = clock
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node", milestoneNeedsApprovalLabel}
+	events := []*githubapi.IssueEvent{
+		milestoneLabelEvent(milestoneNeedsApprovalLabel, milestoneTestBotName, start),
+	}
+	obj := milestoneTestObject(labels, m.activeMilestone, nil, events)
+
+	notification := func() *c.Comment {
+		comment, ok := latestNotificationComment(obj, []string{milestoneTestBotName})
+		if !ok || comment == nil || comment.Body == nil {
+			t.Fatalf("expected a notification comment")
+		}
+		return comment
+	}
+
+	m.Munge(obj)
+	first := notification()
+	if !strings.Contains(*first.Body, "**Heads-up**") || strings.Contains(*first.Body, "**Action required**") {
+		t.Errorf("expected the first notification to use the gentler wording, got %q", *first.Body)
+	}
+
+	// Within the warning interval the heads-up stays put; escalating
+	// the wording on the very next loop would delete and repost it.
+	clock.current = start.Add(time.Hour)
+	m.Munge(obj)
+	if repeat := notification(); *repeat.Body != *first.Body || !repeat.CreatedAt.Equal(*first.CreatedAt) {
+		t.Errorf("expected the heads-up to remain current within the warning interval, got %q", *repeat.Body)
+	}
+
+	// Once the interval has elapsed the warning escalates.
+	clock.current = start.Add(m.warningInterval + time.Hour)
+	m.Munge(obj)
+	if repeat := notification(); !strings.Contains(*repeat.Body, "**Action required**") || strings.Contains(*repeat.Body, "**Heads-up**") {
+		t.Errorf("expected repeat notifications to use the standard wording, got %q", *repeat.Body)
+	}
+}
+
+func TestBusinessDayGrace(t *testing.T) {
+	// The grace label is applied on a Friday evening.
+	labelTime := time.Date(2018, time.May, 11, 18, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		businessDayGrace bool
+		holidays         map[string]bool
+		now              time.Time
+		expected         milestoneState
+	}{
+		"wall-clock grace expires over the weekend": {
+			now:      labelTime.Add(4 * day),
+			expected: milestoneNeedsRemoval,
+		},
+		"business-day grace spans the weekend": {
+			businessDayGrace: true,
+			now:              labelTime.Add(4 * day),
+			expected:         milestoneNeedsLabeling,
+		},
+		"business-day grace still expires eventually": {
+			businessDayGrace: true,
+			now:              time.Date(2018, time.May, 16, 20, 0, 0, 0, time.UTC),
+			expected:         milestoneNeedsRemoval,
+		},
+		"holidays extend the grace period": {
+			businessDayGrace: true,
+			holidays:         map[string]bool{"2018-05-14": true},
+			now:              time.Date(2018, time.May, 16, 20, 0, 0, 0, time.UTC),
+			expected:         milestoneNeedsLabeling,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeDev)
+		m.businessDayGrace = test.businessDayGrace
+		m.holidays = test.holidays
+		m.clock = &fakeClock{current: test.now}
+		labels := []string{"sig/node", milestoneLabelsIncompleteLabel}
+		events := []*githubapi.IssueEvent{
+			milestoneLabelEvent(milestoneLabelsIncompleteLabel, milestoneTestBotName, labelTime),
+		}
+		obj := milestoneTestObject(labels, m.activeMilestone, nil, events)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if icc.state != test.expected {
+			t.Errorf("%s: expected state %v, got %v", testName, test.expected, icc.state)
+		}
+	}
+}
+
+func TestEligibleApprovers(t *testing.T) {
+	tests := map[string]struct {
+		labels           []string
+		expectedReminder string
+	}{
+		"known area approvers are named": {
+			labels:           []string{"kind/bug", "priority/important-soon", "sig/node", "area/kubelet"},
+			expectedReminder: "@alice, @bob",
+		},
+		"an unmapped area falls back to sig mentions": {
+			labels:           []string{"kind/bug", "priority/important-soon", "sig/node", "area/unknown"},
+			expectedReminder: "@kubernetes/sig-node-misc",
+		},
+		"no area label falls back to sig mentions": {
+			labels:           []string{"kind/bug", "priority/important-soon", "sig/node"},
+			expectedReminder: "@kubernetes/sig-node-misc",
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeDev)
+		m.features = features.NewFeatures()
+		m.features.AreaApprovers.AddApprovers("kubelet", "bob", "alice")
+		obj := milestoneTestObject(test.labels, m.activeMilestone, nil, nil)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		body := icc.messageBody()
+		if body == nil || !strings.Contains(*body, test.expectedReminder) {
+			t.Errorf("%s: expected the reminder to mention %q, got %v", testName, test.expectedReminder, body)
+		}
+	}
+}
+
+func TestAttentionColumnSync(t *testing.T) {
+	created := 0
+	deleted := 0
+	cards := "[]"
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/projects/columns/42/cards", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			created++
+			fmt.Fprint(w, `{"id": 7}`)
+			return
+		}
+		fmt.Fprint(w, cards)
+	})
+	mux.HandleFunc("/projects/columns/cards/7", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deleted++
+		}
+	})
+
+	config := &github.Config{Org: "org", Project: "project", BotName: milestoneTestBotName, Endpoint: server.URL}
+	if err := config.PreExecute(); err != nil {
+		t.Fatalf("failed to initialize client: %v", err)
+	}
+	m := milestoneTestMaintainer(milestoneModeSlush)
+	m.attentionColumnID = 42
+
+	issue := github_test.Issue("user", 1, []string{blockerLabel, "sig/node"}, false)
+	issueID := int64(1000)
+	issue.ID = &issueID
+	obj := github.NewTestObject(config, issue, nil, nil)
+
+	// Entering needs-attention adds a card.
+	m.syncAttentionColumn(obj, milestoneNeedsAttention)
+	if created != 1 {
+		t.Fatalf("expected a card to be created, got %d creations", created)
+	}
+
+	// A second pass finds the existing card and does not duplicate it.
+	cards = fmt.Sprintf(`[{"id": 7, "content_url": "%s/repos/org/project/issues/1"}]`, server.URL)
+	m.syncAttentionColumn(obj, milestoneNeedsAttention)
+	if created != 1 {
+		t.Errorf("expected the existing card to be reused, got %d creations", created)
+	}
+
+	// Resolving the state removes the card.
+	m.syncAttentionColumn(obj, milestoneCurrent)
+	if deleted != 1 {
+		t.Errorf("expected the card to be deleted, got %d deletions", deleted)
+	}
+}
+
+func TestIgnoredUpdateActors(t *testing.T) {
+	tests := map[string]struct {
+		ignoredActors  []string
+		expectedWarned bool
+	}{
+		"a ci bot comment resets the clock by default": {},
+		"an ignored actor's comment does not reset the clock": {
+			ignoredActors:  []string{"ci-bot"},
+			expectedWarned: true,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeSlush)
+		m.ignoredUpdateActors = test.ignoredActors
+		labels := []string{"kind/bug", blockerLabel, "sig/node", statusApprovedLabel, statusInProgressLabel}
+		comments := []*githubapi.IssueComment{
+			issueComment(7, "ci-bot", "build succeeded", time.Now().Add(-day)),
+		}
+		obj := milestoneTestObject(labels, m.activeMilestone, comments, nil)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if icc.enabledSections.Has("warnUpdateRequired") != test.expectedWarned {
+			t.Errorf("%s: expected warnUpdateRequired=%t", testName, test.expectedWarned)
+		}
+	}
+}
+
+func TestRemovalCC(t *testing.T) {
+	tests := map[string]struct {
+		labels     []string
+		expectedCC bool
+	}{
+		"removal notifications cc the release team": {
+			labels:     []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel},
+			expectedCC: true,
+		},
+		"warnings do not cc the release team": {
+			labels: []string{"sig/node"},
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeFreeze)
+		m.removalCC = []string{"release-team"}
+		obj := milestoneTestObject(test.labels, m.activeMilestone, nil, nil)
+
+		change := m.issueChange(obj)
+		if change == nil {
+			t.Fatalf("%s: expected an issue change", testName)
+		}
+		if strings.Contains(change.notification.Context, "@release-team") != test.expectedCC {
+			t.Errorf("%s: expected cc=%t, got %q", testName, test.expectedCC, change.notification.Context)
+		}
+	}
+}
+
+func TestLegacyNotificationFormat(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeDev)
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node"}
+
+	// Capture the repeat-notification body the munger would write
+	// today; a legacy comment implies a prior notification, so the
+	// first-contact wording does not apply.
+	capture := milestoneTestMaintainer(milestoneModeDev)
+	capture.warningInterval = 0
+	fresh := milestoneTestObject(labels, capture.activeMilestone, nil, nil)
+	capture.Munge(fresh)
+	capture.Munge(fresh)
+	comment, ok := latestNotificationComment(fresh, []string{milestoneTestBotName})
+	if !ok || comment == nil || comment.Body == nil {
+		t.Fatalf("expected a notification comment")
+	}
+
+	// The same notification in the legacy format wraps the name in
+	// square brackets.
+	fields := strings.SplitN(*comment.Body, " ", 2)
+	legacyBody := fmt.Sprintf("[%s] %s", fields[0], fields[1])
+	legacy := issueComment(5, milestoneTestBotName, legacyBody, time.Now().Add(-time.Hour))
+	obj := milestoneTestObject(append(labels, milestoneNeedsApprovalLabel), m.activeMilestone, []*githubapi.IssueComment{legacy}, nil)
+
+	m.Munge(obj)
+
+	comments, ok := obj.ListComments()
+	if !ok {
+		t.Fatalf("failed to list comments")
+	}
+	if len(comments) != 1 || comments[0].ID == nil || *comments[0].ID != 5 {
+		t.Errorf("expected the legacy comment to be treated as current, got %d comments", len(comments))
+	}
+}
+
+func TestRequireETA(t *testing.T) {
+	tests := map[string]struct {
+		requireETA      bool
+		body            string
+		expectedSection string
+	}{
+		"a recent update without an ETA passes by default": {
+			body: "ACK. In progress",
+		},
+		"an update with an ETA settles the requirement": {
+			requireETA: true,
+			body:       "ACK. In progress\nETA: 01/06/2018",
+		},
+		"an update without an ETA keeps the warning active": {
+			requireETA:      true,
+			body:            "ACK. In progress",
+			expectedSection: "warnMissingETA",
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeFreeze)
+		m.requireETA = test.requireETA
+		labels := []string{"kind/bug", blockerLabel, "sig/node", statusApprovedLabel, statusInProgressLabel}
+		comments := []*githubapi.IssueComment{
+			issueComment(7, "user", test.body, time.Now().Add(-time.Hour)),
+		}
+		obj := milestoneTestObject(labels, m.activeMilestone, comments, nil)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if test.expectedSection == "" {
+			if icc.enabledSections.Has("warnMissingETA") {
+				t.Errorf("%s: expected no missing-ETA warning", testName)
+			}
+			continue
+		}
+		if !icc.enabledSections.Has(test.expectedSection) {
+			t.Errorf("%s: expected %s to be enabled, got %v", testName, test.expectedSection, icc.enabledSections.List())
+		}
+		if body := icc.messageBody(); body == nil || !strings.Contains(*body, "does not include an ETA") {
+			t.Errorf("%s: expected the missing-ETA warning to render, got %v", testName, body)
+		}
+	}
+}
+
+func TestStateLabelUpdateConvergence(t *testing.T) {
+	failRemovals := true
+	added := 0
+	removed := 0
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/repos/org/project/issues/1/labels/", func(w http.ResponseWriter, r *http.Request) {
+		if failRemovals {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		removed++
+		fmt.Fprint(w, "[]")
+	})
+	mux.HandleFunc("/repos/org/project/issues/1/labels", func(w http.ResponseWriter, r *http.Request) {
+		added++
+		fmt.Fprint(w, "[]")
+	})
+
+	config := &github.Config{Org: "org", Project: "project", BotName: milestoneTestBotName, Endpoint: server.URL}
+	if err := config.PreExecute(); err != nil {
+		t.Fatalf("failed to initialize client: %v", err)
+	}
+	m := milestoneTestMaintainer(milestoneModeDev)
+	issue := github_test.Issue("user", 1, []string{milestoneLabelsIncompleteLabel}, false)
+	obj := github.NewTestObject(config, issue, nil, nil)
+
+	// A removal failure aborts before the new label is added, leaving
+	// no conflicting state labels behind.
+	if m.updateMilestoneStateLabel(obj, milestoneNeedsApprovalLabel) {
+		t.Fatalf("expected the update to report failure")
+	}
+	if added != 0 {
+		t.Errorf("expected no label addition while removals fail, got %d", added)
+	}
+
+	// The next loop converges once removals succeed again.
+	failRemovals = false
+	if !m.updateMilestoneStateLabel(obj, milestoneNeedsApprovalLabel) {
+		t.Fatalf("expected the update to succeed")
+	}
+	if removed != 1 || added != 1 {
+		t.Errorf("expected 1 removal and 1 addition, got %d and %d", removed, added)
+	}
+	if obj.HasLabel(milestoneLabelsIncompleteLabel) || !obj.HasLabel(milestoneNeedsApprovalLabel) {
+		t.Errorf("expected only the new state label to remain")
+	}
+}
+
+func TestSingleSigOwner(t *testing.T) {
+	tests := map[string]struct {
+		singleSigOwner bool
+		sigLabels      []string
+		expectedError  string
+	}{
+		"zero sig labels fail in at-least-one mode": {
+			expectedError: "at least one label prefixed",
+		},
+		"one sig label passes in at-least-one mode": {
+			sigLabels: []string{"sig/node"},
+		},
+		"multiple sig labels pass in at-least-one mode": {
+			sigLabels: []string{"sig/node", "sig/storage"},
+		},
+		"zero sig labels fail in exactly-one mode": {
+			singleSigOwner: true,
+			expectedError:  "exactly one label prefixed",
+		},
+		"one sig label passes in exactly-one mode": {
+			singleSigOwner: true,
+			sigLabels:      []string{"sig/node"},
+		},
+		"multiple sig labels fail in exactly-one mode": {
+			singleSigOwner: true,
+			sigLabels:      []string{"sig/node", "sig/storage"},
+			expectedError:  "but found 2",
+		},
+	}
+	for testName, test := range tests {
+		labels := append([]string{"kind/bug", "priority/important-soon"}, test.sigLabels...)
+		issue := github_test.Issue("user", 1, labels, false)
+
+		_, _, _, labelErrors := checkLabels(issue.Labels, "", test.singleSigOwner, nil)
+
+		if test.expectedError == "" {
+			if len(labelErrors) != 0 {
+				t.Errorf("%s: expected no label errors, got %v", testName, labelErrors)
+			}
+			continue
+		}
+		if len(labelErrors) != 1 || !strings.Contains(labelErrors[0], test.expectedError) {
+			t.Errorf("%s: expected an error containing %q, got %v", testName, test.expectedError, labelErrors)
+		}
+	}
+}
+
+func TestExemptAuthors(t *testing.T) {
+	tests := map[string]struct {
+		author          string
+		expectedIgnored bool
+	}{
+		"an exempt bot author is skipped": {
+			author:          "dep-bot",
+			expectedIgnored: true,
+		},
+		"a human author is munged": {
+			author: "user",
+		},
+	}
+	for testName, test := range tests {
+		obj := milestoneTestObject([]string{"sig/node"}, "v1.10", nil, nil)
+		obj.Issue.User = &githubapi.User{Login: &test.author}
+
+		ignored := ignoreObject(obj, "v1.10", nil, false, blockerLabel, false, []string{"dep-bot"}, nil)
+
+		if ignored != test.expectedIgnored {
+			t.Errorf("%s: expected ignored=%t", testName, test.expectedIgnored)
+		}
+	}
+}
+
+func TestTemplateErrorSurfaced(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeDev)
+	// An override that fails to parse makes every render fail.
+	m.messageTemplateOverride = "{{.unclosed"
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node"}
+	obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+
+	if change := m.issueChange(obj); change != nil {
+		t.Fatalf("expected no issue change for a broken template")
+	}
+
+	m.statusLock.Lock()
+	errorCount := m.templateErrorCount
+	m.statusLock.Unlock()
+	if errorCount != 1 {
+		t.Errorf("expected 1 template error to be recorded, got %d", errorCount)
+	}
+	m.publishStatus()
+	recorder := httptest.NewRecorder()
+	m.ServeHTTP(recorder, httptest.NewRequest("GET", "/milestone-status", nil))
+	status := &milestoneStatus{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), status); err != nil {
+		t.Fatalf("failed to decode status payload: %v", err)
+	}
+	if status.TemplateErrors != 1 {
+		t.Errorf("expected the status to report 1 template error, got %d", status.TemplateErrors)
+	}
+}
+
+func TestStaleLabel(t *testing.T) {
+	now := time.Date(2018, time.May, 15, 12, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		comments      []*githubapi.IssueComment
+		initialLabels []string
+		expectedStale bool
+	}{
+		"an untouched issue is labeled stale": {
+			expectedStale: true,
+		},
+		"a recent human comment keeps the issue fresh": {
+			comments: []*githubapi.IssueComment{
+				issueComment(1, "user", "still on it", now.Add(-day)),
+			},
+		},
+		"the stale label is removed on new activity": {
+			comments: []*githubapi.IssueComment{
+				issueComment(1, "user", "back on it", now.Add(-day)),
+			},
+			initialLabels: []string{milestoneStaleLabel},
+		},
+		"a bot comment does not keep the issue fresh": {
+			comments: []*githubapi.IssueComment{
+				issueComment(1, milestoneTestBotName, "ping", now.Add(-day)),
+			},
+			expectedStale: true,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeDev)
+		m.staleThreshold = 7 * day
+		m.clock = &fakeClock{current: now}
+
+		labels := append([]string{"sig/node"}, test.initialLabels...)
+		obj := milestoneTestObject(labels, m.activeMilestone, test.comments, nil)
+
+		m.syncStaleLabel(obj)
+
+		if obj.HasLabel(milestoneStaleLabel) != test.expectedStale {
+			t.Errorf("%s: expected HasLabel(%q) to be %t", testName, milestoneStaleLabel, test.expectedStale)
+		}
+	}
+}
+
+func TestNoSigMentions(t *testing.T) {
+	tests := map[string]struct {
+		noSigMentions      bool
+		expectedSigMention bool
+	}{
+		"sig teams are mentioned by default": {expectedSigMention: true},
+		"sig mentions can be suppressed":     {noSigMentions: true},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeDev)
+		m.noSigMentions = test.noSigMentions
+		labels := []string{"kind/bug", "priority/important-soon", "sig/node"}
+		obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+
+		change := m.issueChange(obj)
+		if change == nil {
+			t.Fatalf("%s: expected an issue change", testName)
+		}
+		mentionLine := strings.SplitN(change.notification.Context, "\n", 2)[0]
+		if !strings.Contains(mentionLine, "@user") {
+			t.Errorf("%s: expected the author to be mentioned, got %q", testName, mentionLine)
+		}
+		if strings.Contains(mentionLine, "@kubernetes/sig-node-misc") != test.expectedSigMention {
+			t.Errorf("%s: expected sig mention presence to be %t, got %q", testName, test.expectedSigMention, mentionLine)
+		}
+	}
+}
+
+func TestRestoreCommand(t *testing.T) {
+	now := time.Date(2018, time.May, 15, 12, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		author           string
+		removedBy        string
+		removedAt        time.Time
+		commandAt        time.Time
+		expectedRestored bool
+	}{
+		"an authorized restore within the window is honored": {
+			author:           "sig-member",
+			removedBy:        milestoneTestBotName,
+			removedAt:        now.Add(-day),
+			commandAt:        now.Add(-time.Hour),
+			expectedRestored: true,
+		},
+		"an unauthorized restore is ignored": {
+			author:    "passer-by",
+			removedBy: milestoneTestBotName,
+			removedAt: now.Add(-day),
+			commandAt: now.Add(-time.Hour),
+		},
+		"a restore outside the window is ignored": {
+			author:    "sig-member",
+			removedBy: milestoneTestBotName,
+			removedAt: now.Add(-10 * day),
+			commandAt: now.Add(-time.Hour),
+		},
+		"a manual removal is not restorable": {
+			author:    "sig-member",
+			removedBy: "human",
+			removedAt: now.Add(-day),
+			commandAt: now.Add(-time.Hour),
+		},
+		"a command posted before the removal is ignored": {
+			author:    "sig-member",
+			removedBy: milestoneTestBotName,
+			removedAt: now.Add(-day),
+			commandAt: now.Add(-2 * day),
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeDev)
+		m.restoreWindow = 7 * day
+		m.clock = &fakeClock{current: now}
+		m.features = features.NewFeatures()
+		m.features.SigMembership.AddMembers("node", "sig-member")
+
+		comments := []*githubapi.IssueComment{
+			issueComment(1, test.author, milestoneRestoreCommand, test.commandAt),
+		}
+		events := []*githubapi.IssueEvent{
+			milestoneLabelEvent(milestoneRemovedLabel, test.removedBy, test.removedAt),
+		}
+		obj := milestoneTestObject([]string{"sig/node", milestoneRemovedLabel}, "", comments, events)
+
+		restored := m.restoreRemoved(obj, m.activeMilestone)
+		if restored != test.expectedRestored {
+			t.Errorf("%s: expected restored=%t", testName, test.expectedRestored)
+		}
+		if obj.HasLabel(milestoneRemovedLabel) == test.expectedRestored {
+			t.Errorf("%s: expected HasLabel(%q) to be %t", testName, milestoneRemovedLabel, !test.expectedRestored)
+		}
+	}
+}
+
+func TestGraceFromFirstWarning(t *testing.T) {
+	now := time.Date(2018, time.May, 15, 12, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		fromFirstWarning bool
+		expectedState    milestoneState
+	}{
+		"a re-added label resets the countdown by default": {
+			expectedState: milestoneNeedsLabeling,
+		},
+		"the first warning anchors the countdown when enabled": {
+			fromFirstWarning: true,
+			expectedState:    milestoneNeedsRemoval,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeDev)
+		m.graceFromFirstWarning = test.fromFirstWarning
+		m.clock = &fakeClock{current: now}
+
+		// The munger warned five days ago; the label has since flapped
+		// and was re-added only an hour ago.
+		comments := []*githubapi.IssueComment{
+			issueComment(3, milestoneTestBotName, "MILESTONENOTIFIER Milestone Labels **Incomplete**\n\nwarning", now.Add(-5*day)),
+		}
+		events := []*githubapi.IssueEvent{
+			milestoneLabelEvent(milestoneLabelsIncompleteLabel, milestoneTestBotName, now.Add(-5*day)),
+			milestoneLabelEvent(milestoneLabelsIncompleteLabel, milestoneTestBotName, now.Add(-time.Hour)),
+		}
+		labels := []string{"sig/node", milestoneLabelsIncompleteLabel}
+		obj := milestoneTestObject(labels, m.activeMilestone, comments, events)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if icc.state != test.expectedState {
+			t.Errorf("%s: expected state %q, got %q", testName, test.expectedState, icc.state)
+		}
+	}
+}
+
+func TestRemovalExemptPriorities(t *testing.T) {
+	tests := map[string]struct {
+		exemptPriorities []string
+		expectedState    milestoneState
+	}{
+		"a non-blocker is removed during freeze by default": {
+			expectedState: milestoneNeedsRemoval,
+		},
+		"an exempt priority is warned but kept": {
+			exemptPriorities: []string{"priority/important-longterm"},
+			expectedState:    milestoneNeedsAttention,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeFreeze)
+		m.removalExemptPriorities = test.exemptPriorities
+
+		labels := []string{"kind/bug", "priority/important-longterm", "sig/node", statusApprovedLabel}
+		obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if icc.state != test.expectedState {
+			t.Errorf("%s: expected state %q, got %q", testName, test.expectedState, icc.state)
+		}
+	}
+}
+
+func TestStateSeverity(t *testing.T) {
+	tests := map[string]struct {
+		severity      []milestoneState
+		expectedState milestoneState
+	}{
+		"the default ordering prefers needs-attention over at-risk": {
+			expectedState: milestoneNeedsAttention,
+		},
+		"a custom ordering can prefer at-risk": {
+			severity:      []milestoneState{milestoneAtRisk, milestoneNeedsAttention, milestoneCurrent},
+			expectedState: milestoneAtRisk,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeSlush)
+		m.stateSeverity = test.severity
+
+		// The blocker is missing the in-progress label and is past the
+		// at-risk threshold of its update interval, qualifying it for
+		// two states at once.
+		labels := []string{"kind/bug", blockerLabel, "sig/node", statusApprovedLabel}
+		comments := []*githubapi.IssueComment{
+			issueComment(4, "user", "working on it", time.Now().Add(-61*time.Hour)),
+		}
+		obj := milestoneTestObject(labels, m.activeMilestone, comments, nil)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if !icc.enabledSections.HasAll("warnMissingInProgress", "warnUpdateAtRisk") {
+			t.Fatalf("%s: expected both conditions to apply, got %v", testName, icc.enabledSections.List())
+		}
+		if icc.state != test.expectedState {
+			t.Errorf("%s: expected state %q, got %q", testName, test.expectedState, icc.state)
+		}
+	}
+}
+
+func TestInProgressModes(t *testing.T) {
+	tests := map[string]struct {
+		mode       string
+		modes      sets.String
+		expectWarn bool
+	}{
+		"the default requires in-progress during slush": {
+			mode:       milestoneModeSlush,
+			expectWarn: true,
+		},
+		"the default requires in-progress during freeze": {
+			mode:       milestoneModeFreeze,
+			expectWarn: true,
+		},
+		"a freeze-only configuration skips the requirement in slush": {
+			mode:       milestoneModeSlush,
+			modes:      sets.NewString(milestoneModeFreeze),
+			expectWarn: false,
+		},
+		"a freeze-only configuration enforces the requirement in freeze": {
+			mode:       milestoneModeFreeze,
+			modes:      sets.NewString(milestoneModeFreeze),
+			expectWarn: true,
+		},
+		"a slush-only configuration skips the requirement in freeze": {
+			mode:       milestoneModeFreeze,
+			modes:      sets.NewString(milestoneModeSlush),
+			expectWarn: false,
+		},
+		"an empty configuration disables the requirement": {
+			mode:       milestoneModeSlush,
+			modes:      sets.NewString(),
+			expectWarn: false,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(test.mode)
+		m.inProgressModes = test.modes
+
+		// A recently-updated blocker missing the in-progress label.
+		labels := []string{"kind/bug", blockerLabel, "sig/node", statusApprovedLabel}
+		comments := []*githubapi.IssueComment{
+			issueComment(4, "user", "working on it", time.Now().Add(-time.Hour)),
+		}
+		obj := milestoneTestObject(labels, m.activeMilestone, comments, nil)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if warned := icc.enabledSections.Has("warnMissingInProgress"); warned != test.expectWarn {
+			t.Errorf("%s: expected warnMissingInProgress to be %t, got %t", testName, test.expectWarn, warned)
+		}
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeDev)
+	m.quietHoursStart = 22 * 60
+	m.quietHoursEnd = 7 * 60
+	clock := &fakeClock{current: time.Date(2018, time.May, 15, 3, 0, 0, 0, time.UTC)}
+	m.clock = clock
+
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node"}
+	obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+
+	// During quiet hours the label is applied but the comment is
+	// withheld.
+	m.Munge(obj)
+	if !obj.HasLabel(milestoneNeedsApprovalLabel) {
+		t.Fatalf("expected the state label to be applied during quiet hours")
+	}
+	if comment, ok := latestNotificationComment(obj, []string{milestoneTestBotName}); !ok || comment != nil {
+		t.Fatalf("expected no notification during quiet hours, got %v", comment)
+	}
+
+	// The next loop after the window closes posts the comment.
+	clock.current = time.Date(2018, time.May, 15, 8, 0, 0, 0, time.UTC)
+	m.Munge(obj)
+	comment, ok := latestNotificationComment(obj, []string{milestoneTestBotName})
+	if !ok || comment == nil {
+		t.Fatalf("expected a notification after quiet hours")
+	}
+}
+
+func TestInQuietHours(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeDev)
+	m.quietHoursStart = 22 * 60
+	m.quietHoursEnd = 7 * 60
+
+	at := func(hour int) time.Time {
+		return time.Date(2018, time.May, 15, hour, 0, 0, 0, time.UTC)
+	}
+	if !m.inQuietHours(at(23)) || !m.inQuietHours(at(3)) {
+		t.Errorf("expected the midnight-spanning window to cover late night hours")
+	}
+	if m.inQuietHours(at(12)) {
+		t.Errorf("expected midday to be outside quiet hours")
+	}
+
+	// The window is evaluated in the configured timezone.
+	location, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("unable to load location: %v", err)
+	}
+	m.quietHoursLocation = location
+	if !m.inQuietHours(at(12)) {
+		t.Errorf("expected noon UTC to fall within west-coast quiet hours")
+	}
+}
+
+func TestApplyPriorityCommands(t *testing.T) {
+	tests := map[string]struct {
+		author        string
+		expectedState milestoneState
+	}{
+		"an authorized promotion to blocker prevents removal": {
+			author:        "sig-member",
+			expectedState: milestoneCurrent,
+		},
+		"an unauthorized priority command is ignored": {
+			author:        "passer-by",
+			expectedState: milestoneNeedsRemoval,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeFreeze)
+		m.features = features.NewFeatures()
+		m.features.SigMembership.AddMembers("node", "sig-member")
+
+		// A non-blocker in freeze would be removed; the command promotes
+		// it to blocker within the same munge.
+		labels := []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel, statusInProgressLabel}
+		comments := []*githubapi.IssueComment{
+			issueComment(1, test.author, milestonePriorityCommand+" critical-urgent", time.Now()),
+		}
+		obj := milestoneTestObject(labels, m.activeMilestone, comments, nil)
+
+		m.applyPriorityCommands(obj)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if icc.state != test.expectedState {
+			t.Errorf("%s: expected state %q, got %q", testName, test.expectedState, icc.state)
+		}
+		expectedBlocker := test.expectedState != milestoneNeedsRemoval
+		if obj.HasLabel(blockerLabel) != expectedBlocker {
+			t.Errorf("%s: expected HasLabel(%q) to be %t", testName, blockerLabel, expectedBlocker)
+		}
+	}
+}
+
+func TestStructuredLogging(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	m := milestoneTestMaintainer(milestoneModeFreeze)
+	m.logger = newMilestoneLogger(buffer)
+
+	// A non-blocker in freeze is a removal candidate.
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel}
+	obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+
+	if change := m.issueChange(obj); change == nil {
+		t.Fatalf("expected an issue change")
+	}
+
+	record := map[string]interface{}{}
+	if err := json.Unmarshal(buffer.Bytes(), &record); err != nil {
+		t.Fatalf("failed to decode the log record: %v", err)
+	}
+	if record["issue"] != float64(1) {
+		t.Errorf("expected issue 1 in the log record, got %v", record["issue"])
+	}
+	if record["state"] != milestoneNeedsRemoval.String() {
+		t.Errorf("expected state %q in the log record, got %v", milestoneNeedsRemoval, record["state"])
+	}
+	if record["mode"] != milestoneModeFreeze {
+		t.Errorf("expected mode %q in the log record, got %v", milestoneModeFreeze, record["mode"])
+	}
+	if sections, ok := record["sections"].([]interface{}); !ok || len(sections) == 0 {
+		t.Errorf("expected enabled sections in the log record, got %v", record["sections"])
+	}
+}
+
+func milestoneChangeEvent(eventType, milestone string, createdAt time.Time) *githubapi.IssueEvent {
+	event := &githubapi.IssueEvent{
+		Event:     &eventType,
+		CreatedAt: &createdAt,
+	}
+	if milestone != "" {
+		event.Milestone = &githubapi.Milestone{Title: &milestone}
+	}
+	return event
+}
+
+func TestMilestoneCacheStale(t *testing.T) {
+	tests := map[string]struct {
+		milestone string
+		events    []*githubapi.IssueEvent
+		expected  bool
+	}{
+		"no milestone events": {
+			milestone: "v1.10",
+			expected:  false,
+		},
+		"the most recent event agrees with the cache": {
+			milestone: "v1.10",
+			events: []*githubapi.IssueEvent{
+				milestoneChangeEvent("milestoned", "v1.10", time.Now().Add(-time.Minute)),
+			},
+			expected: false,
+		},
+		"the most recent event contradicts the cache": {
+			milestone: "v1.10",
+			events: []*githubapi.IssueEvent{
+				milestoneChangeEvent("milestoned", "v1.10", time.Now().Add(-time.Hour)),
+				milestoneChangeEvent("milestoned", "v1.9", time.Now().Add(-time.Minute)),
+			},
+			expected: true,
+		},
+		"a recent removal contradicts a cached milestone": {
+			milestone: "v1.10",
+			events: []*githubapi.IssueEvent{
+				milestoneChangeEvent("milestoned", "v1.10", time.Now().Add(-time.Hour)),
+				milestoneChangeEvent("demilestoned", "v1.10", time.Now().Add(-time.Minute)),
+			},
+			expected: true,
+		},
+	}
+	for testName, test := range tests {
+		obj := milestoneTestObject([]string{"sig/node"}, test.milestone, nil, test.events)
+		if stale := milestoneCacheStale(obj); stale != test.expected {
+			t.Errorf("%s: expected stale to be %t, got %t", testName, test.expected, stale)
+		}
+	}
+
+	// A stale issue is skipped entirely rather than acted on.
+	m := milestoneTestMaintainer(milestoneModeDev)
+	events := []*githubapi.IssueEvent{
+		milestoneChangeEvent("milestoned", "v1.9", time.Now().Add(-time.Minute)),
+	}
+	obj := milestoneTestObject([]string{"sig/node"}, m.activeMilestone, nil, events)
+	m.Munge(obj)
+	if obj.HasLabel(milestoneLabelsIncompleteLabel) {
+		t.Errorf("expected the stale issue to be skipped, not labeled")
+	}
+}
+
+func TestRemovalMode(t *testing.T) {
+	tests := map[string]struct {
+		removalMode     string
+		expectMilestone string
+	}{
+		"clear mode removes the milestone": {
+			removalMode:     milestoneRemovalModeClear,
+			expectMilestone: "",
+		},
+		"label-only mode leaves the milestone in place": {
+			removalMode:     milestoneRemovalModeLabelOnly,
+			expectMilestone: "v1.10",
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeFreeze)
+		m.removalMode = test.removalMode
+
+		labels := []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel}
+		obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+		m.Munge(obj)
+
+		if !obj.HasLabel(milestoneRemovedLabel) {
+			t.Errorf("%s: expected %q to be applied", testName, milestoneRemovedLabel)
+		}
+		if comment, ok := latestNotificationComment(obj, []string{milestoneTestBotName}); !ok || comment == nil {
+			t.Errorf("%s: expected a removal notification to be posted", testName)
+		}
+		if milestone, _ := obj.ReleaseMilestone(); milestone != test.expectMilestone {
+			t.Errorf("%s: expected milestone %q, got %q", testName, test.expectMilestone, milestone)
+		}
+	}
+}
+
+func TestValidateGracePeriods(t *testing.T) {
+	tests := map[string]struct {
+		labelGracePeriod time.Duration
+		strict           bool
+		expectErr        bool
+	}{
+		"a consistent configuration passes": {
+			labelGracePeriod: 3 * day,
+		},
+		"an inconsistent configuration only warns by default": {
+			labelGracePeriod: time.Hour,
+		},
+		"an inconsistent configuration errors under strict validation": {
+			labelGracePeriod: time.Hour,
+			strict:           true,
+			expectErr:        true,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeDev)
+		m.warningInterval = 24 * time.Hour
+		m.labelGracePeriod = test.labelGracePeriod
+		m.strictConfigValidation = test.strict
+
+		err := m.validateGracePeriods()
+		if test.expectErr && err == nil {
+			t.Errorf("%s: expected an error", testName)
+		}
+		if !test.expectErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", testName, err)
+		}
+	}
+}
+
+func TestSummarizeLinkedPRs(t *testing.T) {
+	crossReferenced := "cross-referenced"
+	url := "https://api.github.com/repos/org/project/issues/99"
+	timeline := []*githubapi.Timeline{{Event: &crossReferenced, Source: &githubapi.Source{URL: &url}}}
+	openPR := github_test.Issue("author", 99, nil, true)
+
+	for _, enabled := range []bool{true, false} {
+		m := milestoneTestMaintainer(milestoneModeDev)
+		m.summarizeLinkedPRs = enabled
+
+		labels := []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel}
+		issue := github_test.Issue("user", 1, labels, false)
+		issue.Milestone = &githubapi.Milestone{Title: &m.activeMilestone}
+		config := &github.Config{Org: "org", Project: "project", BotName: milestoneTestBotName, DryRun: true}
+		obj := github.NewTestObjectWithTimeline(config, issue, nil, nil, timeline, []*githubapi.Issue{openPR})
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("enabled=%t: expected an issue change config", enabled)
+		}
+		body := icc.messageBody()
+		if body == nil {
+			t.Fatalf("enabled=%t: expected a message body", enabled)
+		}
+		if listed := strings.Contains(*body, "Linked PRs: #99"); listed != enabled {
+			t.Errorf("enabled=%t: expected linked PRs listed to be %t, got %q", enabled, enabled, *body)
+		}
+	}
+}
+
+func TestForceRemoveCommand(t *testing.T) {
+	now := time.Date(2018, time.May, 15, 12, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		author        string
+		commandAt     time.Time
+		removedAt     time.Time
+		expectedState milestoneState
+	}{
+		"an authorized removal request takes effect immediately": {
+			author:        "sig-member",
+			commandAt:     now.Add(-time.Hour),
+			expectedState: milestoneNeedsRemoval,
+		},
+		"an unauthorized removal request is ignored": {
+			author:        "passer-by",
+			commandAt:     now.Add(-time.Hour),
+			expectedState: milestoneCurrent,
+		},
+		"a command predating the last automated removal is stale": {
+			author:        "sig-member",
+			commandAt:     now.Add(-2 * day),
+			removedAt:     now.Add(-day),
+			expectedState: milestoneCurrent,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeDev)
+		m.clock = &fakeClock{current: now}
+		m.features = features.NewFeatures()
+		m.features.SigMembership.AddMembers("node", "sig-member")
+
+		comments := []*githubapi.IssueComment{
+			issueComment(1, test.author, milestoneRemoveCommand, test.commandAt),
+		}
+		var events []*githubapi.IssueEvent
+		if !test.removedAt.IsZero() {
+			events = append(events, milestoneLabelEvent(milestoneRemovedLabel, milestoneTestBotName, test.removedAt))
+		}
+		labels := []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel}
+		obj := milestoneTestObject(labels, m.activeMilestone, comments, events)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if icc.state != test.expectedState {
+			t.Errorf("%s: expected state %v, got %v", testName, test.expectedState, icc.state)
+		}
+		if test.expectedState != milestoneNeedsRemoval {
+			continue
+		}
+		if icc.removalReason != milestoneRemovalReasonByRequest {
+			t.Errorf("%s: expected removal reason %q, got %q", testName, milestoneRemovalReasonByRequest, icc.removalReason)
+		}
+		body := icc.messageBody()
+		if body == nil || !strings.Contains(*body, "at the request of @sig-member") {
+			t.Errorf("%s: expected the removal comment to name the requester, got %v", testName, body)
+		}
+	}
+}
+
+func TestAreaSigMapping(t *testing.T) {
+	tests := map[string]struct {
+		mapping       map[string]string
+		expectedState milestoneState
+		expectedSigs  string
+	}{
+		"area labels alone are incomplete without a mapping": {
+			expectedState: milestoneNeedsLabeling,
+		},
+		"a mapped area label derives the owning sig": {
+			mapping:       map[string]string{"area/kubelet": "sig/node"},
+			expectedState: milestoneCurrent,
+			expectedSigs:  "@kubernetes/sig-node-misc",
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeDev)
+		m.areaSigMap = test.mapping
+
+		labels := []string{"kind/bug", "priority/important-soon", "area/kubelet", statusApprovedLabel}
+		obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if icc.state != test.expectedState {
+			t.Errorf("%s: expected state %v, got %v", testName, test.expectedState, icc.state)
+		}
+		if mentions := icc.sigMentions(); mentions != test.expectedSigs {
+			t.Errorf("%s: expected sig mentions %q, got %q", testName, test.expectedSigs, mentions)
+		}
+	}
+
+	if _, err := parseAreaSigMapping("kubelet=sig/node"); err == nil {
+		t.Errorf("expected an error for an area label without the %q prefix", areaLabelPrefix)
+	}
+}
+
+func TestNotificationIdempotencyKey(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeDev)
+	labels := []string{"kind/bug", "priority/important-soon", "sig/node"}
+	obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+
+	change := m.issueChange(obj)
+	if change == nil {
+		t.Fatalf("expected an issue change")
+	}
+	key := notificationKeyIn(change.notification.String())
+	if key == "" {
+		t.Fatalf("expected the notification to embed an idempotency key")
+	}
+
+	// The first post goes through.
+	if err := m.postNotification(obj, change.notification); err != nil {
+		t.Fatalf("unexpected error posting notification: %v", err)
+	}
+	comments, _ := obj.ListComments()
+	if len(comments) != 1 {
+		t.Fatalf("expected 1 comment, got %d", len(comments))
+	}
+
+	// A racing loop that already decided to post the same notification
+	// finds the key and backs off.
+	if err := m.postNotification(obj, change.notification); err != nil {
+		t.Fatalf("unexpected error from duplicate post: %v", err)
+	}
+	comments, _ = obj.ListComments()
+	if len(comments) != 1 {
+		t.Errorf("expected the duplicate post to be skipped, got %d comments", len(comments))
+	}
+
+	// A notification with different content carries a different key and
+	// is not suppressed.
+	other := c.NewNotification(milestoneNotifierName, "Milestone Labels **Incomplete**", fmt.Sprintf("changed\n%s", fmt.Sprintf(milestoneNotificationKeyMarker, notificationKey(milestoneNeedsLabeling, "changed"))))
+	if err := m.postNotification(obj, other); err != nil {
+		t.Fatalf("unexpected error posting changed notification: %v", err)
+	}
+	if comments, _ = obj.ListComments(); len(comments) != 2 {
+		t.Errorf("expected the changed notification to post, got %d comments", len(comments))
+	}
+}
+
+type fakeMailer struct {
+	messages []string
+	err      error
+}
+
+func (f *fakeMailer) Send(to, subject, body string) error {
+	f.messages = append(f.messages, fmt.Sprintf("%s|%s|%s", to, subject, body))
+	return f.err
+}
+
+func TestEmailNotification(t *testing.T) {
+	tests := map[string]struct {
+		sigEmails     map[string]string
+		mailerErr     error
+		expectedMails int
+	}{
+		"a configured sig is emailed on removal": {
+			sigEmails:     map[string]string{"sig/node": "node-leads@example.com"},
+			expectedMails: 1,
+		},
+		"an unconfigured sig is not emailed": {
+			sigEmails: map[string]string{"sig/docs": "docs-leads@example.com"},
+		},
+		"a delivery failure does not block the removal": {
+			sigEmails:     map[string]string{"sig/node": "node-leads@example.com"},
+			mailerErr:     fmt.Errorf("smtp unavailable"),
+			expectedMails: 1,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeFreeze)
+		mailer := &fakeMailer{err: test.mailerErr}
+		m.mailer = mailer
+		m.sigEmails = test.sigEmails
+
+		labels := []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel}
+		obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+		m.Munge(obj)
+
+		if len(mailer.messages) != test.expectedMails {
+			t.Errorf("%s: expected %d emails, got %d", testName, test.expectedMails, len(mailer.messages))
+		}
+		if test.expectedMails > 0 {
+			message := mailer.messages[0]
+			if !strings.HasPrefix(message, "node-leads@example.com|") {
+				t.Errorf("%s: expected the email to address the sig, got %q", testName, message)
+			}
+			if !strings.Contains(message, "Milestone **Removed**") {
+				t.Errorf("%s: expected the subject to carry the notification title, got %q", testName, message)
+			}
+			if !strings.Contains(message, "@user") {
+				t.Errorf("%s: expected the body to mention the issue users, got %q", testName, message)
+			}
+		}
+		if comment, ok := latestNotificationComment(obj, []string{milestoneTestBotName}); !ok || comment == nil {
+			t.Errorf("%s: expected the github notification to be posted regardless", testName)
+		}
+		if milestone, _ := obj.ReleaseMilestone(); milestone != "" {
+			t.Errorf("%s: expected the milestone to be cleared regardless, got %q", testName, milestone)
+		}
+	}
+
+	if _, err := parseSigEmails("sig/node=not-an-address"); err == nil {
+		t.Errorf("expected an error for an invalid email address")
+	}
+}
+
+func TestStatusQueryCommand(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeDev)
+	comments := []*githubapi.IssueComment{
+		issueComment(1, "user", milestoneStatusCommand, time.Now().Add(-time.Hour)),
+	}
+	obj := milestoneTestObject([]string{"sig/node"}, m.activeMilestone, comments, nil)
+
+	m.handleStatusQueries(obj)
+
+	issueComments, _ := obj.ListComments()
+	response := c.FilterComments(c.FromIssueComments(issueComments), c.MungerNotificationNames(milestoneStatusQueryNotifierName, m.botNames())).GetLast()
+	if response == nil {
+		t.Fatalf("expected a status reply to be posted")
+	}
+	notification := c.ParseNotification(response)
+	if !strings.Contains(notification.Context, "@user") {
+		t.Errorf("expected the reply to address the requester, got %q", notification.Context)
+	}
+	if !strings.Contains(notification.Context, fmt.Sprintf("`%s`", milestoneNeedsLabeling)) {
+		t.Errorf("expected the reply to name the computed state, got %q", notification.Context)
+	}
+	if !strings.Contains(notification.Context, "warnIncompleteLabels") {
+		t.Errorf("expected the reply to list the enabled conditions, got %q", notification.Context)
+	}
+	if obj.HasLabel(milestoneLabelsIncompleteLabel) {
+		t.Errorf("expected the query to change no labels")
+	}
+
+	// An already-answered command is not answered again.
+	m.handleStatusQueries(obj)
+	issueComments, _ = obj.ListComments()
+	responses := c.FilterComments(c.FromIssueComments(issueComments), c.MungerNotificationNames(milestoneStatusQueryNotifierName, m.botNames()))
+	if len(responses) != 1 {
+		t.Errorf("expected 1 status reply, got %d", len(responses))
+	}
+}
+
+func TestIgnoreTitlePrefixes(t *testing.T) {
+	tests := map[string]struct {
+		title    string
+		prefixes []string
+		expected bool
+	}{
+		"a placeholder-titled issue is skipped": {
+			title:    "[PLACEHOLDER] tracker for the foo feature",
+			prefixes: []string{"[PLACEHOLDER]"},
+			expected: true,
+		},
+		"a regular title is considered": {
+			title:    "kubelet crashes on startup",
+			prefixes: []string{"[PLACEHOLDER]"},
+			expected: false,
+		},
+		"no configured prefixes considers everything": {
+			title:    "[PLACEHOLDER] tracker for the foo feature",
+			expected: false,
+		},
+	}
+	for testName, test := range tests {
+		issue := github_test.Issue("user", 1, nil, false)
+		issue.Title = &test.title
+		milestone := "v1.10"
+		issue.Milestone = &githubapi.Milestone{Title: &milestone}
+		obj := github.NewTestObject(&github.Config{DryRun: true}, issue, nil, nil)
+		if ignored := ignoreObject(obj, "v1.10", nil, false, blockerLabel, false, nil, test.prefixes); ignored != test.expected {
+			t.Errorf("%s: expected ignoreObject to return %t, got %t", testName, test.expected, ignored)
+		}
+	}
+}
+
+func TestEventsFetchedOnce(t *testing.T) {
+	fetches := 0
+	failing := false
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/repos/org/project/issues/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"number": 1, "state": "open", "user": {"login": "user"}, "created_at": "2018-05-01T12:00:00Z"}`)
+	})
+	mux.HandleFunc("/repos/org/project/issues/1/events", func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `[{"event": "labeled", "label": {"name": "sig/node"}, "actor": {"login": "user"}, "created_at": "2018-05-02T12:00:00Z"}]`)
+	})
+
+	config := &github.Config{Org: "org", Project: "project", BotName: milestoneTestBotName, Endpoint: server.URL}
+	if err := config.PreExecute(); err != nil {
+		t.Fatalf("failed to initialize client: %v", err)
+	}
+	obj, err := config.NewObject(1)
+	if err != nil {
+		t.Fatalf("failed to fetch issue: %v", err)
+	}
+
+	// Several consumers of the events within a single munge share one
+	// fetch.
+	now := time.Date(2018, time.May, 15, 12, 0, 0, 0, time.UTC)
+	if _, ok := labelLastCreatedAt(obj, []string{milestoneTestBotName}, "sig/node"); !ok {
+		t.Fatalf("expected the label creation time to be found")
+	}
+	if _, ok := gracePeriodStart(obj, []string{milestoneTestBotName}, "sig/node", now, false); !ok {
+		t.Fatalf("expected the grace period start to be found")
+	}
+	if _, ok := obj.GetEvents(); !ok {
+		t.Fatalf("expected the cached events to be returned")
+	}
+	if fetches != 1 {
+		t.Errorf("expected the events to be fetched once, got %d fetches", fetches)
+	}
+
+	// A failed fetch is not retried within the same munge.
+	failing = true
+	obj, err = config.NewObject(1)
+	if err != nil {
+		t.Fatalf("failed to fetch issue: %v", err)
+	}
+	fetches = 0
+	if _, ok := obj.GetEvents(); ok {
+		t.Fatalf("expected the event fetch to fail")
+	}
+	if _, ok := obj.GetEvents(); ok {
+		t.Fatalf("expected the failure to be remembered")
+	}
+	if fetches != 1 {
+		t.Errorf("expected the failed fetch to not be retried, got %d fetches", fetches)
+	}
+}
+
+func TestRequireBlockerAssignee(t *testing.T) {
+	assignee := "owner"
+	tests := map[string]struct {
+		require    bool
+		assignees  []*githubapi.User
+		expectWarn bool
+	}{
+		"an unassigned blocker is warned about under the option": {
+			require:    true,
+			expectWarn: true,
+		},
+		"an assigned blocker satisfies the requirement": {
+			require:    true,
+			assignees:  []*githubapi.User{{Login: &assignee}},
+			expectWarn: false,
+		},
+		"an unassigned blocker is left alone by default": {
+			require:    false,
+			expectWarn: false,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeFreeze)
+		m.requireBlockerAssignee = test.require
+
+		// A recently-updated, in-progress blocker.
+		labels := []string{"kind/bug", blockerLabel, "sig/node", statusApprovedLabel, statusInProgressLabel}
+		comments := []*githubapi.IssueComment{
+			issueComment(4, "user", "working on it", time.Now().Add(-time.Hour)),
+		}
+		obj := milestoneTestObject(labels, m.activeMilestone, comments, nil)
+		obj.Issue.Assignees = test.assignees
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if warned := icc.enabledSections.Has("warnMissingAssignee"); warned != test.expectWarn {
+			t.Errorf("%s: expected warnMissingAssignee to be %t, got %t", testName, test.expectWarn, warned)
+		}
+		if test.expectWarn {
+			if icc.state != milestoneNeedsAttention {
+				t.Errorf("%s: expected state %q, got %q", testName, milestoneNeedsAttention, icc.state)
+			}
+			if body := icc.messageBody(); body == nil || !strings.Contains(*body, "has no assignee") {
+				t.Errorf("%s: expected the notification to request an assignee, got %v", testName, body)
+			}
+		}
+	}
+}
+
+func TestRemovalExemptMilestones(t *testing.T) {
+	tests := map[string]struct {
+		exemptMilestones []string
+		expectedState    milestoneState
+	}{
+		"a non-blocker is removed during freeze by default": {
+			expectedState: milestoneNeedsRemoval,
+		},
+		"an exempt milestone is warned but kept": {
+			exemptMilestones: []string{"v1.10"},
+			expectedState:    milestoneNeedsAttention,
+		},
+		"an exemption for another milestone does not apply": {
+			exemptMilestones: []string{"v1.9.7"},
+			expectedState:    milestoneNeedsRemoval,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeFreeze)
+		m.removalExemptMilestones = test.exemptMilestones
+
+		labels := []string{"kind/bug", "priority/important-longterm", "sig/node", statusApprovedLabel}
+		obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if icc.state != test.expectedState {
+			t.Errorf("%s: expected state %q, got %q", testName, test.expectedState, icc.state)
+		}
+
+		m.Munge(obj)
+		comments, _ := obj.ListComments()
+		if len(comments) != 1 {
+			t.Fatalf("%s: expected a notification comment, got %d", testName, len(comments))
+		}
+		milestone, _ := obj.ReleaseMilestone()
+		if test.expectedState == milestoneNeedsAttention && milestone != m.activeMilestone {
+			t.Errorf("%s: expected the exempt issue to stay in the milestone, got %q", testName, milestone)
+		}
+		if test.expectedState == milestoneNeedsRemoval && milestone != "" {
+			t.Errorf("%s: expected the issue to be removed from the milestone, got %q", testName, milestone)
+		}
+	}
+}
+
+func TestNewTestMilestoneMaintainer(t *testing.T) {
+	// Overrides take effect while everything unset gets the option
+	// defaults.
+	m := NewTestMilestoneMaintainer(MilestoneMaintainerSpec{
+		ActiveMilestone:      "v1.11",
+		Mode:                 milestoneModeSlush,
+		SlushUpdateInterval:  12 * time.Hour,
+		FreezeUpdateInterval: 6 * time.Hour,
+	})
+	if m.activeMilestone != "v1.11" || m.mode != milestoneModeSlush {
+		t.Errorf("expected the spec to be applied, got milestone %q mode %q", m.activeMilestone, m.mode)
+	}
+	if m.slushUpdateInterval != 12*time.Hour {
+		t.Errorf("expected the slush update interval override, got %s", m.slushUpdateInterval)
+	}
+	if m.approvalGracePeriod != 7*day || m.warningInterval != day || m.atRiskPercent != 75 {
+		t.Errorf("expected option defaults for unset fields, got grace %s interval %s at-risk %d", m.approvalGracePeriod, m.warningInterval, m.atRiskPercent)
+	}
+
+	// The constructed maintainer can drive issueChangeConfig directly.
+	labels := []string{"kind/bug", blockerLabel, "sig/node", statusApprovedLabel, statusInProgressLabel}
+	comments := []*githubapi.IssueComment{
+		issueComment(4, "user", "working on it", time.Now().Add(-24*time.Hour)),
+	}
+	obj := milestoneTestObject(labels, "v1.11", comments, nil)
+	icc := m.issueChangeConfig(obj)
+	if icc == nil {
+		t.Fatalf("expected an issue change config")
+	}
+	if !icc.enabledSections.Has("warnUpdateRequired") {
+		t.Errorf("expected the blocker to be past its overridden update interval, got %v", icc.enabledSections.List())
+	}
+}
+
+func TestCloseOnFinalRemoval(t *testing.T) {
+	tests := map[string]struct {
+		final       bool
+		expectClose bool
+	}{
+		"removal from the final milestone closes the issue": {
+			final:       true,
+			expectClose: true,
+		},
+		"removal from a non-final milestone only clears the milestone": {
+			final:       false,
+			expectClose: false,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeFreeze)
+		m.closeOnFinalRemoval = test.final
+
+		// A non-blocker during freeze is a removal candidate.
+		labels := []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel}
+		obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+		m.Munge(obj)
+
+		if milestone, _ := obj.ReleaseMilestone(); milestone != "" {
+			t.Errorf("%s: expected the milestone to be cleared, got %q", testName, milestone)
+		}
+		closed := obj.Issue.State != nil && *obj.Issue.State == "closed"
+		if closed != test.expectClose {
+			t.Errorf("%s: expected closed to be %t, got %t", testName, test.expectClose, closed)
+		}
+		comments, _ := obj.ListComments()
+		explained := false
+		for _, comment := range comments {
+			if comment.Body != nil && strings.Contains(*comment.Body, "has been closed") {
+				explained = true
+			}
+		}
+		if explained != test.expectClose {
+			t.Errorf("%s: expected a closing comment to be %t, got %t (comments: %d)", testName, test.expectClose, explained, len(comments))
+		}
+	}
+}
+
+func TestSigMentionOverrides(t *testing.T) {
+	overrides := map[string]string{"sig/architecture": "@kubernetes/sig-architecture"}
+
+	// The override replaces the templated handle for its sig only.
+	mentions := sigMentions([]string{"sig/architecture", "sig/node"}, defaultSigMentionTemplate, overrides)
+	if mentions != "@kubernetes/sig-architecture @kubernetes/sig-node-misc" {
+		t.Errorf("expected the override to apply to its sig only, got %q", mentions)
+	}
+
+	// An override shared with a templated handle is still deduplicated.
+	mentions = sigMentions([]string{"sig/architecture", "sig/architecture"}, defaultSigMentionTemplate, overrides)
+	if mentions != "@kubernetes/sig-architecture" {
+		t.Errorf("expected duplicate targets to collapse, got %q", mentions)
+	}
+
+	if _, err := parseSigMentionOverrides("sig/architecture=@kubernetes/sig-architecture,sig/node=@kubernetes/sig-node"); err != nil {
+		t.Errorf("unexpected error parsing a valid spec: %v", err)
+	}
+	if _, err := parseSigMentionOverrides("area/kubelet=@kubernetes/sig-node"); err == nil {
+		t.Errorf("expected an error for a non-sig key")
+	}
+	if _, err := parseSigMentionOverrides("sig/node=kubernetes/sig-node"); err == nil {
+		t.Errorf("expected an error for a handle without '@'")
+	}
+}
+
+func TestCleanupOrphanedStateLabels(t *testing.T) {
+	tests := map[string]struct {
+		cleanup     bool
+		milestone   string
+		expectStrip bool
+	}{
+		"orphaned labels are stripped from a reassigned issue": {
+			cleanup:     true,
+			milestone:   "v1.11",
+			expectStrip: true,
+		},
+		"a reassigned issue is left alone when the option is off": {
+			cleanup:     false,
+			milestone:   "v1.11",
+			expectStrip: false,
+		},
+		"a removed issue keeps its labels for the restore flow": {
+			cleanup:     true,
+			milestone:   "",
+			expectStrip: false,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeFreeze)
+		m.cleanupOrphaned = test.cleanup
+
+		labels := []string{"kind/bug", "sig/node", milestoneLabelsIncompleteLabel, milestoneRemovedLabel}
+		obj := milestoneTestObject(labels, test.milestone, nil, nil)
+		m.Munge(obj)
+
+		stripped := len(currentStateLabels(obj)) == 0
+		if stripped != test.expectStrip {
+			t.Errorf("%s: expected stripped to be %t, got labels %v", testName, test.expectStrip, currentStateLabels(obj))
+		}
+		if comments, _ := obj.ListComments(); len(comments) != 0 {
+			t.Errorf("%s: expected no comments on an ignored issue, got %d", testName, len(comments))
+		}
+	}
+}
+
+func TestNotificationJitter(t *testing.T) {
+	jitter := 4 * time.Hour
+	m := milestoneTestMaintainer(milestoneModeDev)
+	m.notificationJitter = jitter
+
+	// The issue's slot is deterministic, so the loop right before it
+	// holds the notification and the loop at the slot posts it.
+	labels := []string{"sig/node"}
+	obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+	slot := notificationJitterSlot(obj.Number(), jitter)
+	windowStart := time.Date(2018, time.May, 15, 12, 0, 0, 0, time.UTC).Truncate(jitter)
+	clock := &fakeClock{current: windowStart}
+	m.clock = clock
+
+	if slot > 0 {
+		m.Munge(obj)
+		comments, _ := obj.ListComments()
+		if len(comments) != 0 {
+			t.Fatalf("expected the notification to be held before the slot, got %d comments", len(comments))
+		}
+		if !obj.HasLabel(milestoneLabelsIncompleteLabel) {
+			t.Errorf("expected labels to be maintained while the notification is held")
+		}
+	}
+
+	clock.current = windowStart.Add(slot)
+	m.Munge(obj)
+	comments, _ := obj.ListComments()
+	if len(comments) != 1 {
+		t.Fatalf("expected the notification to post at the slot, got %d comments", len(comments))
+	}
+
+	// Distinct issues land in distinct loops rather than all at once.
+	slots := sets.NewString()
+	for number := 1; number <= 8; number++ {
+		slots.Insert(notificationJitterSlot(number, jitter).String())
+	}
+	if slots.Len() < 2 {
+		t.Errorf("expected issue numbers to spread over the window, got %v", slots.List())
+	}
+
+	// Disabling the jitter posts immediately.
+	m = milestoneTestMaintainer(milestoneModeDev)
+	m.clock = &fakeClock{current: windowStart}
+	obj = milestoneTestObject(labels, m.activeMilestone, nil, nil)
+	m.Munge(obj)
+	if comments, _ := obj.ListComments(); len(comments) != 1 {
+		t.Errorf("expected an immediate notification without jitter, got %d comments", len(comments))
+	}
+}
+
+func TestInProgressLabelSet(t *testing.T) {
+	tests := map[string]struct {
+		configured []string
+		labels     []string
+		expectWarn bool
+	}{
+		"the canonical label satisfies the default": {
+			labels:     []string{statusInProgressLabel},
+			expectWarn: false,
+		},
+		"an alternate label does not satisfy the default": {
+			labels:     []string{"status/started"},
+			expectWarn: true,
+		},
+		"a configured alternate label satisfies the requirement": {
+			configured: []string{statusInProgressLabel, "status/started"},
+			labels:     []string{"status/started"},
+			expectWarn: false,
+		},
+		"no recognized label still warns under a configured set": {
+			configured: []string{statusInProgressLabel, "status/started"},
+			labels:     []string{},
+			expectWarn: true,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeFreeze)
+		m.inProgressLabels = test.configured
+
+		labels := append([]string{"kind/bug", blockerLabel, "sig/node", statusApprovedLabel}, test.labels...)
+		comments := []*githubapi.IssueComment{
+			issueComment(4, "user", "working on it", time.Now().Add(-time.Hour)),
+		}
+		obj := milestoneTestObject(labels, m.activeMilestone, comments, nil)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if warned := icc.enabledSections.Has("warnMissingInProgress"); warned != test.expectWarn {
+			t.Errorf("%s: expected warnMissingInProgress to be %t, got %t", testName, test.expectWarn, warned)
+		}
+	}
+}
+
+func TestRequireDesignDoc(t *testing.T) {
+	body := "A feature with no design link."
+	tests := map[string]struct {
+		require    bool
+		kind       string
+		body       string
+		comment    string
+		expectWarn bool
+	}{
+		"a feature without a design link is warned about": {
+			require:    true,
+			kind:       featureKindLabel,
+			body:       body,
+			expectWarn: true,
+		},
+		"a design link in the description satisfies the requirement": {
+			require:    true,
+			kind:       featureKindLabel,
+			body:       "Design: https://docs.google.com/document/d/abc123",
+			expectWarn: false,
+		},
+		"a design link in a comment satisfies the requirement": {
+			require:    true,
+			kind:       featureKindLabel,
+			body:       body,
+			comment:    "See https://github.com/kubernetes/community/tree/master/contributors/design-proposals/node.md",
+			expectWarn: false,
+		},
+		"a bug is not subject to the requirement": {
+			require:    true,
+			kind:       "kind/bug",
+			body:       body,
+			expectWarn: false,
+		},
+		"the requirement is off by default": {
+			require:    false,
+			kind:       featureKindLabel,
+			body:       body,
+			expectWarn: false,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeSlush)
+		m.requireDesignDoc = test.require
+
+		labels := []string{test.kind, "priority/important-soon", "sig/node", statusApprovedLabel}
+		comments := []*githubapi.IssueComment{
+			issueComment(4, "user", "working on it", time.Now().Add(-time.Hour)),
+		}
+		if test.comment != "" {
+			comments = append(comments, issueComment(5, "user", test.comment, time.Now().Add(-time.Hour)))
+		}
+		obj := milestoneTestObject(labels, m.activeMilestone, comments, nil)
+		obj.Issue.Body = &test.body
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if warned := icc.enabledSections.Has("warnMissingDesignDoc"); warned != test.expectWarn {
+			t.Errorf("%s: expected warnMissingDesignDoc to be %t, got %t", testName, test.expectWarn, warned)
+		}
+		if test.expectWarn {
+			if body := icc.messageBody(); body == nil || !strings.Contains(*body, "design doc or proposal") {
+				t.Errorf("%s: expected the notification to request a design link, got %v", testName, body)
+			}
+		}
+	}
+}
+
+func TestStateLabelDiff(t *testing.T) {
+	labelsFor := func(names ...string) []githubapi.Label {
+		labels := []githubapi.Label{}
+		for i := range names {
+			labels = append(labels, githubapi.Label{Name: &names[i]})
+		}
+		return labels
+	}
+	tests := map[string]struct {
+		labels          []githubapi.Label
+		target          string
+		expectedAdds    []string
+		expectedRemoves []string
+	}{
+		"a state transition removes the old label and adds the new one": {
+			labels:          labelsFor("sig/node", milestoneNeedsApprovalLabel),
+			target:          milestoneLabelsIncompleteLabel,
+			expectedAdds:    []string{milestoneLabelsIncompleteLabel},
+			expectedRemoves: []string{milestoneNeedsApprovalLabel},
+		},
+		"clearing the state only removes": {
+			labels:          labelsFor("sig/node", milestoneNeedsAttentionLabel, milestoneAtRiskLabel),
+			target:          "",
+			expectedRemoves: []string{milestoneAtRiskLabel, milestoneNeedsAttentionLabel},
+		},
+		"an already-correct label yields an empty diff": {
+			labels: labelsFor("sig/node", milestoneNeedsApprovalLabel),
+			target: milestoneNeedsApprovalLabel,
+		},
+		"non-state labels are never part of the diff": {
+			labels:       labelsFor("sig/node", "kind/bug"),
+			target:       milestoneNeedsTriageLabel,
+			expectedAdds: []string{milestoneNeedsTriageLabel},
+		},
+	}
+	for testName, test := range tests {
+		adds, removes := stateLabelDiff(test.labels, test.target)
+		if !reflect.DeepEqual(adds, test.expectedAdds) {
+			t.Errorf("%s: expected adds %v, got %v", testName, test.expectedAdds, adds)
+		}
+		if !reflect.DeepEqual(removes, test.expectedRemoves) {
+			t.Errorf("%s: expected removes %v, got %v", testName, test.expectedRemoves, removes)
+		}
+	}
+
+	// The diff drives the actual label maintenance.
+	m := milestoneTestMaintainer(milestoneModeDev)
+	obj := milestoneTestObject([]string{"sig/node", milestoneNeedsApprovalLabel}, m.activeMilestone, nil, nil)
+	if !m.updateMilestoneStateLabel(obj, milestoneLabelsIncompleteLabel) {
+		t.Fatalf("expected the label update to succeed")
+	}
+	if obj.HasLabel(milestoneNeedsApprovalLabel) || !obj.HasLabel(milestoneLabelsIncompleteLabel) {
+		t.Errorf("expected the transition to be applied, got %v", currentStateLabels(obj))
+	}
+}
+
+func TestGraphQLPrefetch(t *testing.T) {
+	graphqlCalls := 0
+	restListCalls := 0
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/repos/org/project/issues/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"number": 1, "state": "open", "user": {"login": "user"}, "created_at": "2018-05-01T12:00:00Z"}`)
+	})
+	mux.HandleFunc("/repos/org/project/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		restListCalls++
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/repos/org/project/issues/1/events", func(w http.ResponseWriter, r *http.Request) {
+		restListCalls++
+		fmt.Fprint(w, `[]`)
+	})
+	// A recorded response carrying labels, milestone, one comment and
+	// one label event.
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected the GraphQL query to be POSTed, got %s", r.Method)
+		}
+		graphqlCalls++
+		fmt.Fprint(w, `{"data": {"repository": {"issue": {
+			"body": "a bug",
+			"milestone": {"title": "v1.10"},
+			"labels": {"nodes": [{"name": "sig/node"}]},
+			"comments": {"nodes": [{"databaseId": 7, "body": "working on it", "createdAt": "2018-05-02T12:00:00Z", "url": "https://example.com/c/7", "author": {"login": "user"}}]},
+			"timelineItems": {"nodes": [{"__typename": "LabeledEvent", "createdAt": "2018-05-02T12:00:00Z", "actor": {"login": "user"}, "label": {"name": "sig/node"}}]}
+		}}}}`)
+	})
+
+	config := &github.Config{Org: "org", Project: "project", BotName: milestoneTestBotName, Endpoint: server.URL, DryRun: true}
+	if err := config.PreExecute(); err != nil {
+		t.Fatalf("failed to initialize client: %v", err)
+	}
+	obj, err := config.NewObject(1)
+	if err != nil {
+		t.Fatalf("failed to fetch issue: %v", err)
+	}
+
+	if !obj.PrefetchGraphQL() {
+		t.Fatalf("expected the prefetch to succeed")
+	}
+	if graphqlCalls != 1 {
+		t.Errorf("expected a single GraphQL query, got %d", graphqlCalls)
+	}
+	if !obj.HasLabel("sig/node") {
+		t.Errorf("expected the labels to be seeded")
+	}
+	if milestone, _ := obj.ReleaseMilestone(); milestone != "v1.10" {
+		t.Errorf("expected the milestone to be seeded, got %q", milestone)
+	}
+	comments, ok := obj.ListComments()
+	if !ok || len(comments) != 1 || comments[0].Body == nil || *comments[0].Body != "working on it" {
+		t.Fatalf("expected the seeded comment, got %v", comments)
+	}
+	events, ok := obj.GetEvents()
+	if !ok || len(events) != 1 || events[0].Event == nil || *events[0].Event != "labeled" {
+		t.Fatalf("expected the seeded label event, got %v", events)
+	}
+	if restListCalls != 0 {
+		t.Errorf("expected the seeded caches to satisfy the listings, got %d REST calls", restListCalls)
+	}
+
+	// The prefetched state feeds the usual change computation.
+	m := milestoneTestMaintainer(milestoneModeFreeze)
+	icc := m.issueChangeConfig(obj)
+	if icc == nil {
+		t.Fatalf("expected an issue change config")
+	}
+	if icc.state != milestoneNeedsLabeling {
+		t.Errorf("expected state %q from the prefetched labels, got %q", milestoneNeedsLabeling, icc.state)
+	}
+}
+
+func TestGraphQLPrefetchTruncated(t *testing.T) {
+	restListCalls := 0
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/repos/org/project/issues/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"number": 1, "state": "open", "user": {"login": "user"}, "created_at": "2018-05-01T12:00:00Z"}`)
+	})
+	mux.HandleFunc("/repos/org/project/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		restListCalls++
+		fmt.Fprint(w, `[]`)
+	})
+	// The comments connection reports a further page, meaning the most
+	// recent comments are missing from the response.
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": {"repository": {"issue": {
+			"body": "a bug",
+			"labels": {"nodes": [{"name": "sig/node"}]},
+			"comments": {"pageInfo": {"hasNextPage": true}, "nodes": [{"databaseId": 7, "body": "oldest comment", "createdAt": "2018-05-02T12:00:00Z", "url": "https://example.com/c/7", "author": {"login": "user"}}]},
+			"timelineItems": {"nodes": []}
+		}}}}`)
+	})
+
+	config := &github.Config{Org: "org", Project: "project", BotName: milestoneTestBotName, Endpoint: server.URL, DryRun: true}
+	if err := config.PreExecute(); err != nil {
+		t.Fatalf("failed to initialize client: %v", err)
+	}
+	obj, err := config.NewObject(1)
+	if err != nil {
+		t.Fatalf("failed to fetch issue: %v", err)
+	}
+
+	if obj.PrefetchGraphQL() {
+		t.Fatalf("expected the prefetch to fail on a truncated listing")
+	}
+	if comments, ok := obj.ListComments(); !ok || len(comments) != 0 {
+		t.Fatalf("expected the truncated comments to be discarded, got %v", comments)
+	}
+	if restListCalls != 1 {
+		t.Errorf("expected the comment listing to fall back to REST, got %d calls", restListCalls)
+	}
+}
+
+func TestReleaseBranchPRBlocksRemoval(t *testing.T) {
+	crossReferenced := "cross-referenced"
+	linkTimeline := func(number int) []*githubapi.Timeline {
+		url := fmt.Sprintf("https://api.github.com/repos/org/project/issues/%d", number)
+		return []*githubapi.Timeline{{Event: &crossReferenced, Source: &githubapi.Source{URL: &url}}}
+	}
+	openPR := github_test.Issue("author", 99, nil, true)
+
+	tests := map[string]struct {
+		releaseBranch string
+		baseBranch    string
+		expected      milestoneState
+	}{
+		"a pending release-branch PR keeps the issue in the milestone": {
+			releaseBranch: "release-1.10",
+			baseBranch:    "release-1.10",
+			expected:      milestoneNeedsAttention,
+		},
+		"a PR targeting another branch does not": {
+			releaseBranch: "release-1.10",
+			baseBranch:    "master",
+			expected:      milestoneNeedsRemoval,
+		},
+		"an empty release branch disables the check": {
+			baseBranch: "release-1.10",
+			expected:   milestoneNeedsRemoval,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeFreeze)
+		m.releaseBranch = test.releaseBranch
+		labels := []string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel}
+		issue := github_test.Issue("user", 1, labels, false)
+		issue.Milestone = &githubapi.Milestone{Title: &m.activeMilestone}
+		config := &github.Config{Org: "org", Project: "project", BotName: milestoneTestBotName, DryRun: true}
+		obj := github.NewTestObjectWithTimeline(config, issue, nil, nil, linkTimeline(99), []*githubapi.Issue{openPR})
+		obj.SetPRBaseBranch(99, test.baseBranch)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if icc.state != test.expected {
+			t.Errorf("%s: expected state %v, got %v", testName, test.expected, icc.state)
+		}
+	}
+}
+
+func TestDefaultPriority(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeDev)
+	m.defaultPriority = "priority/important-longterm"
+	labels := []string{"kind/bug", "sig/node", statusApprovedLabel}
+	obj := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+
+	m.Munge(obj)
+
+	if !obj.HasLabel("priority/important-longterm") {
+		t.Fatalf("expected the default priority label to be applied")
+	}
+	if obj.HasLabel(milestoneLabelsIncompleteLabel) {
+		t.Errorf("expected no incomplete-labels warning after the default was applied")
+	}
+	comments, ok := obj.ListComments()
+	if !ok {
+		t.Fatalf("expected to list comments")
+	}
+	found := false
+	for _, comment := range comments {
+		if comment.Body != nil && strings.Contains(*comment.Body, "default priority") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected an informational comment noting the default priority")
+	}
+
+	// An invalid default - possible because the options framework
+	// applies raw values before the validating callback - is ignored.
+	m.defaultPriority = "area/node"
+	invalid := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+	m.Munge(invalid)
+	if invalid.HasLabel("area/node") {
+		t.Errorf("expected an invalid default priority to be ignored")
+	}
+
+	// Labels-only mode applies the label but posts no comment.
+	m.defaultPriority = "priority/important-longterm"
+	m.labelsOnly = true
+	quiet := milestoneTestObject(labels, m.activeMilestone, nil, nil)
+	m.Munge(quiet)
+	if !quiet.HasLabel("priority/important-longterm") {
+		t.Errorf("expected the default priority label in labels-only mode")
+	}
+	if comments, ok := quiet.ListComments(); !ok || len(comments) != 0 {
+		t.Errorf("expected no comments in labels-only mode, got %v", comments)
+	}
+}
+
+func TestApprovalFromReview(t *testing.T) {
+	crossReferenced := "cross-referenced"
+	url := "https://api.github.com/repos/org/project/issues/99"
+	timeline := []*githubapi.Timeline{{Event: &crossReferenced, Source: &githubapi.Source{URL: &url}}}
+	openPR := github_test.Issue("author", 99, nil, true)
+
+	review := func(login, state string) *githubapi.PullRequestReview {
+		return &githubapi.PullRequestReview{User: &githubapi.User{Login: &login}, State: &state}
+	}
+
+	tests := map[string]struct {
+		enabled  bool
+		reviews  []*githubapi.PullRequestReview
+		expected milestoneState
+	}{
+		"an approving review from a sig member satisfies approval": {
+			enabled:  true,
+			reviews:  []*githubapi.PullRequestReview{review("sig-member", "APPROVED")},
+			expected: milestoneCurrent,
+		},
+		"a non-approving review does not": {
+			enabled:  true,
+			reviews:  []*githubapi.PullRequestReview{review("sig-member", "COMMENTED")},
+			expected: milestoneNeedsApproval,
+		},
+		"an approving review from a non-member does not": {
+			enabled:  true,
+			reviews:  []*githubapi.PullRequestReview{review("outsider", "APPROVED")},
+			expected: milestoneNeedsApproval,
+		},
+		"the option off still requires the label": {
+			reviews:  []*githubapi.PullRequestReview{review("sig-member", "APPROVED")},
+			expected: milestoneNeedsApproval,
+		},
+	}
+	for testName, test := range tests {
+		m := milestoneTestMaintainer(milestoneModeDev)
+		m.approvalFromReview = test.enabled
+		m.features = features.NewFeatures()
+		m.features.SigMembership.AddMembers("node", "sig-member")
+		labels := []string{"kind/bug", "priority/important-soon", "sig/node"}
+		issue := github_test.Issue("user", 1, labels, false)
+		issue.Milestone = &githubapi.Milestone{Title: &m.activeMilestone}
+		config := &github.Config{Org: "org", Project: "project", BotName: milestoneTestBotName, DryRun: true}
+		obj := github.NewTestObjectWithTimeline(config, issue, nil, nil, timeline, []*githubapi.Issue{openPR})
+		obj.SetPRReviews(99, test.reviews)
+
+		icc := m.issueChangeConfig(obj)
+		if icc == nil {
+			t.Fatalf("%s: expected an issue change config", testName)
+		}
+		if icc.state != test.expected {
+			t.Errorf("%s: expected state %v, got %v", testName, test.expected, icc.state)
+		}
+	}
+}
+
+func TestCountdownExcludedFromMatch(t *testing.T) {
+	body := func(countdown string) string {
+		return fmt.Sprintf("**Action required**: approval is needed. If the label is not applied within "+milestoneCountdownTemplate+", the issue will be moved out of the v1.10 milestone.", countdown)
+	}
+	notification := c.NewNotification(milestoneNotifierName, "Milestone Issue **Needs Approval**", body("2 days 3 hours"))
+	// The posted comment is an hour older, so its countdown differs.
+	posted := c.NewNotification(milestoneNotifierName, "Milestone Issue **Needs Approval**", body("2 days 4 hours"))
+	comment := c.FromIssueComment(issueComment(1, milestoneTestBotName, posted.String(), time.Now()))
+	if !notificationsMatch(notification, comment) {
+		t.Errorf("expected notifications differing only in the countdown to match")
+	}
+	other := c.NewNotification(milestoneNotifierName, "Milestone Issue **Needs Approval**", "a different warning")
+	if notificationsMatch(other, comment) {
+		t.Errorf("expected notifications with different bodies not to match")
+	}
+}
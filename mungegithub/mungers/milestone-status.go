@@ -0,0 +1,499 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mungers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	githubapi "github.com/google/go-github/github"
+
+	"k8s.io/test-infra/mungegithub/github"
+	c "k8s.io/test-infra/mungegithub/mungers/matchers/comment"
+)
+
+// milestoneIssue identifies an issue in the milestone status payload.
+type milestoneIssue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+}
+
+// milestoneStatus is a machine-readable snapshot of the health of the
+// active milestone, summarizing the state computed for each issue
+// during the most recent munge loop.
+type milestoneStatus struct {
+	Milestone  string                      `json:"milestone"`
+	Mode       string                      `json:"mode"`
+	FreezeDate string                      `json:"freezeDate,omitempty"`
+	Counts     map[string]int              `json:"counts"`
+	Issues     map[string][]milestoneIssue `json:"issues"`
+	// TemplateErrors counts notification template rendering failures
+	// since startup; a non-zero value indicates issues being dropped.
+	TemplateErrors int `json:"templateErrors"`
+}
+
+// newStatus returns an empty status for the active milestone. Callers
+// must hold statusLock, and optionsLock for reading.
+func (m *MilestoneMaintainer) newStatus() *milestoneStatus {
+	return &milestoneStatus{
+		Milestone:      m.activeMilestone,
+		Mode:           m.effectiveMode(m.now()),
+		FreezeDate:     m.freezeDate,
+		Counts:         map[string]int{},
+		Issues:         map[string][]milestoneIssue{},
+		TemplateErrors: m.templateErrorCount,
+	}
+}
+
+// recordTemplateError counts a notification template rendering
+// failure, for the status endpoint to report on.
+func (m *MilestoneMaintainer) recordTemplateError() {
+	m.statusLock.Lock()
+	defer m.statusLock.Unlock()
+	m.templateErrorCount++
+}
+
+// recordIssueState adds the computed state of an issue to the status
+// being accumulated for the current munge loop.
+func (m *MilestoneMaintainer) recordIssueState(obj *github.MungeObject, state milestoneState) {
+	m.statusLock.Lock()
+	defer m.statusLock.Unlock()
+	if m.nextStatus == nil {
+		m.nextStatus = m.newStatus()
+	}
+	stateName := state.String()
+	m.nextStatus.Counts[stateName]++
+	if state == milestoneCurrent {
+		return
+	}
+	issue := milestoneIssue{Number: obj.Number()}
+	if obj.Issue.Title != nil {
+		issue.Title = *obj.Issue.Title
+	}
+	m.nextStatus.Issues[stateName] = append(m.nextStatus.Issues[stateName], issue)
+}
+
+// publishStatus makes the status accumulated during the just-completed
+// munge loop available to the status endpoint and starts accumulating
+// a new one.
+func (m *MilestoneMaintainer) publishStatus() {
+	m.statusLock.Lock()
+	defer m.statusLock.Unlock()
+	if m.nextStatus != nil {
+		m.currentStatus = m.nextStatus
+	}
+	m.nextStatus = m.newStatus()
+}
+
+// milestoneRollupNotifierName names the rollup notification posted on
+// the tracking issue, keeping it distinct from per-issue notifications.
+const milestoneRollupNotifierName = "MilestoneRollup"
+
+// postTrackingSummary posts a rollup of the most recently published
+// status as a notification comment on the configured tracking issue.
+// An existing rollup comment is replaced rather than duplicated, and
+// an unchanged rollup is left alone. The rollup is best-effort:
+// failures are logged without failing the loop.
+func (m *MilestoneMaintainer) postTrackingSummary() {
+	if m.trackingIssueNumber <= 0 || m.config == nil {
+		return
+	}
+	m.statusLock.Lock()
+	status := m.currentStatus
+	m.statusLock.Unlock()
+	if status == nil {
+		return
+	}
+	obj, err := m.config.NewObject(m.trackingIssueNumber)
+	if err != nil {
+		glog.Errorf("Failed to fetch tracking issue %d: %v", m.trackingIssueNumber, err)
+		return
+	}
+	notification := c.NewNotification(milestoneRollupNotifierName, "", trackingSummaryBody(status))
+	comment, ok := latestRollupComment(obj, m.botNames())
+	if !ok {
+		return
+	}
+	if notificationsMatch(notification, comment) {
+		return
+	}
+	if comment != nil {
+		if err := obj.DeleteComment(comment.Source.(*githubapi.IssueComment)); err != nil {
+			glog.Errorf("Failed to delete the stale rollup on %d: %v", m.trackingIssueNumber, err)
+			return
+		}
+	}
+	if err := notification.Post(obj); err != nil {
+		glog.Errorf("Failed to post the rollup on %d: %v", m.trackingIssueNumber, err)
+	}
+}
+
+// trackingSummaryBody renders the rollup comment posted on the
+// tracking issue: issue counts by state, followed by the list of
+// at-risk issues when there are any.
+func trackingSummaryBody(status *milestoneStatus) string {
+	lines := []string{fmt.Sprintf("Issue counts for the %s milestone (mode: %s):", status.Milestone, status.Mode)}
+	stateNames := []string{}
+	for stateName := range status.Counts {
+		stateNames = append(stateNames, stateName)
+	}
+	sort.Strings(stateNames)
+	for _, stateName := range stateNames {
+		lines = append(lines, fmt.Sprintf("- %s: %d", stateName, status.Counts[stateName]))
+	}
+	if atRisk := status.Issues[milestoneAtRisk.String()]; len(atRisk) > 0 {
+		lines = append(lines, "", "At-risk issues:")
+		for _, issue := range atRisk {
+			lines = append(lines, fmt.Sprintf("- #%d: %s", issue.Number, issue.Title))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// latestRollupComment returns the most recent rollup notification the
+// munger has posted on the tracking issue.
+func latestRollupComment(obj *github.MungeObject, botNames []string) (*c.Comment, bool) {
+	issueComments, ok := obj.ListComments()
+	if !ok {
+		return nil, false
+	}
+	notifications := c.FilterComments(c.FromIssueComments(issueComments), c.MungerNotificationNames(milestoneRollupNotifierName, botNames))
+	return notifications.GetLast(), true
+}
+
+// milestoneReconcileResult summarizes a batch reconcile pass.
+type milestoneReconcileResult struct {
+	Considered int            `json:"considered"`
+	Skipped    int            `json:"skipped"`
+	Counts     map[string]int `json:"counts"`
+	DryRun     bool           `json:"dryRun"`
+}
+
+// reconcileObjects runs the normal munge logic over the given issues
+// immediately and summarizes the states they were left in.
+func (m *MilestoneMaintainer) reconcileObjects(objects []*github.MungeObject) *milestoneReconcileResult {
+	result := &milestoneReconcileResult{
+		Counts: map[string]int{},
+		DryRun: m.config != nil && m.config.DryRun,
+	}
+	for _, obj := range objects {
+		// The skip evaluation reads option-backed fields, so it takes
+		// the options lock; Munge takes its own read lock, so the lock
+		// is not held across it.
+		m.optionsLock.RLock()
+		skip := ignoreObject(obj, m.activeMilestoneFor(obj), m.titlePattern, m.autoReaddBlockers, m.blockerLabel, m.cleanupClosed, m.exemptAuthors, m.ignoreTitlePrefixes) || !m.sigAllowed(obj)
+		m.optionsLock.RUnlock()
+		if skip {
+			result.Skipped++
+			continue
+		}
+		result.Considered++
+		m.Munge(obj)
+		stateName := milestoneCurrent.String()
+		if stateLabels := currentStateLabels(obj); len(stateLabels) > 0 {
+			stateName = stateNameForLabel(stateLabels[0])
+		}
+		result.Counts[stateName]++
+	}
+	return result
+}
+
+// handleReconcile triggers an immediate re-evaluation of every open
+// issue in the active milestone, without waiting for the next munge
+// loop. Mutations respect dry-run mode as usual.
+func (m *MilestoneMaintainer) handleReconcile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST required", http.StatusMethodNotAllowed)
+		return
+	}
+	if m.config == nil {
+		http.Error(w, "munger not initialized", http.StatusServiceUnavailable)
+		return
+	}
+	objects := []*github.MungeObject{}
+	if err := m.config.ForEachIssueDo(func(obj *github.MungeObject) error {
+		objects = append(objects, obj)
+		return nil
+	}); err != nil {
+		glog.Errorf("Failed to list issues for reconcile: %v", err)
+		http.Error(w, "failed to list issues", http.StatusInternalServerError)
+		return
+	}
+	result := m.reconcileObjects(objects)
+	glog.Infof("Reconciled %d issues (%d skipped): %v", result.Considered, result.Skipped, result.Counts)
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		glog.Errorf("Failed to encode reconcile result: %v", err)
+		http.Error(w, "failed to encode result", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// milestonePreviewRequest describes the fake issue for which a
+// notification should be rendered.
+type milestonePreviewRequest struct {
+	// Labels is the set of labels carried by the fake issue.
+	Labels []string `json:"labels"`
+	// Mode overrides the configured milestone mode.
+	Mode string `json:"mode,omitempty"`
+	// SinceUpdate is how long ago (e.g. "96h") the issue was last
+	// updated by a human.
+	SinceUpdate string `json:"sinceUpdate,omitempty"`
+	// SinceLabeled is how long ago any state labels in Labels were
+	// applied by the munger, for exercising grace period countdowns.
+	SinceLabeled string `json:"sinceLabeled,omitempty"`
+}
+
+// milestonePreviewResponse carries the state and rendered notification
+// body computed for the fake issue.
+type milestonePreviewResponse struct {
+	State string `json:"state"`
+	Body  string `json:"body"`
+}
+
+// handlePreview renders the notification the munger would post for a
+// fake issue described by the request, so that template and option
+// changes can be iterated on without touching real issues.
+func (m *MilestoneMaintainer) handlePreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST required", http.StatusMethodNotAllowed)
+		return
+	}
+	request := &milestonePreviewRequest{}
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	m.optionsLock.RLock()
+	defer m.optionsLock.RUnlock()
+
+	mode := m.effectiveMode(m.now())
+	if request.Mode != "" {
+		if !milestoneModes.Has(request.Mode) {
+			http.Error(w, fmt.Sprintf("mode must be one of %v", milestoneModes.List()), http.StatusBadRequest)
+			return
+		}
+		mode = request.Mode
+	}
+	obj, err := m.previewObject(request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	icc := m.issueChangeConfigForMode(obj, mode)
+	if icc == nil {
+		http.Error(w, "unable to compute an issue change config", http.StatusInternalServerError)
+		return
+	}
+	response := &milestonePreviewResponse{State: icc.state.String()}
+	if body := icc.messageBody(); body != nil {
+		response.Body = *body
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		glog.Errorf("Failed to encode preview response: %v", err)
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// previewObject constructs a fake issue matching the preview request.
+func (m *MilestoneMaintainer) previewObject(request *milestonePreviewRequest) (*github.MungeObject, error) {
+	now := m.now()
+	number := 1
+	state := "open"
+	author := "preview-author"
+	createdAt := now.Add(-90 * day)
+	milestone := m.activeMilestone
+	labels := []githubapi.Label{}
+	for i := range request.Labels {
+		labels = append(labels, githubapi.Label{Name: &request.Labels[i]})
+	}
+	issue := &githubapi.Issue{
+		Number:    &number,
+		State:     &state,
+		User:      &githubapi.User{Login: &author},
+		Labels:    labels,
+		CreatedAt: &createdAt,
+		Milestone: &githubapi.Milestone{Title: &milestone},
+	}
+
+	comments := []*githubapi.IssueComment{}
+	if request.SinceUpdate != "" {
+		sinceUpdate, err := time.ParseDuration(request.SinceUpdate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sinceUpdate: %v", err)
+		}
+		commentID := int64(1)
+		author := "preview-author"
+		body := "Issue update"
+		commentedAt := now.Add(-sinceUpdate)
+		comments = append(comments, &githubapi.IssueComment{
+			ID:        &commentID,
+			User:      &githubapi.User{Login: &author},
+			Body:      &body,
+			CreatedAt: &commentedAt,
+		})
+	}
+
+	events := []*githubapi.IssueEvent{}
+	if request.SinceLabeled != "" {
+		sinceLabeled, err := time.ParseDuration(request.SinceLabeled)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sinceLabeled: %v", err)
+		}
+		labeled := "labeled"
+		labeledAt := now.Add(-sinceLabeled)
+		for i := range request.Labels {
+			events = append(events, &githubapi.IssueEvent{
+				Event:     &labeled,
+				Label:     &githubapi.Label{Name: &request.Labels[i]},
+				Actor:     &githubapi.User{Login: &m.botName},
+				CreatedAt: &labeledAt,
+			})
+		}
+	}
+
+	config := &github.Config{Org: "preview", Project: "preview", BotName: m.botName, DryRun: true}
+	return github.NewTestObject(config, issue, comments, events), nil
+}
+
+// milestoneHealth reports whether the munger has completed a munge
+// loop recently enough to be considered alive.
+type milestoneHealth struct {
+	Healthy  bool   `json:"healthy"`
+	LastLoop string `json:"lastLoop,omitempty"`
+}
+
+// handleHealth reports unhealthy - with a 503 status for ops probes -
+// when the last completed munge loop is older than the configured
+// threshold, so that stalled automation gets noticed.
+func (m *MilestoneMaintainer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	m.optionsLock.RLock()
+	healthThreshold := m.healthThreshold
+	m.optionsLock.RUnlock()
+
+	m.statusLock.Lock()
+	lastLoop := m.lastLoopTime
+	m.statusLock.Unlock()
+
+	health := &milestoneHealth{Healthy: true}
+	if !lastLoop.IsZero() {
+		health.LastLoop = lastLoop.Format(time.RFC3339)
+	}
+	if healthThreshold > 0 {
+		health.Healthy = !lastLoop.IsZero() && m.now().Sub(lastLoop) <= healthThreshold
+	}
+
+	data, err := json.Marshal(health)
+	if err != nil {
+		glog.Errorf("Failed to encode health status: %v", err)
+		http.Error(w, "failed to encode health status", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !health.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(data)
+}
+
+// milestoneMetrics reports the github API call tallies, for budgeting
+// the munger's rate limit consumption.
+type milestoneMetrics struct {
+	LastLoop map[string]int `json:"lastLoop"`
+	Total    map[string]int `json:"total"`
+}
+
+// recordAPICallCounts snapshots the github API calls made during the
+// completed munge loop and folds them into the running totals.
+func (m *MilestoneMaintainer) recordAPICallCounts() {
+	if m.config == nil {
+		return
+	}
+	counts := m.config.ResetAPICallCounts()
+	m.statusLock.Lock()
+	defer m.statusLock.Unlock()
+	m.lastLoopAPICalls = counts
+	if m.totalAPICalls == nil {
+		m.totalAPICalls = map[string]int{}
+	}
+	for callType, count := range counts {
+		m.totalAPICalls[callType] += count
+	}
+}
+
+// handleMetrics serves the github API call counts of the most recent
+// munge loop, and the totals since startup, as JSON.
+func (m *MilestoneMaintainer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	m.statusLock.Lock()
+	metrics := &milestoneMetrics{
+		LastLoop: map[string]int{},
+		Total:    map[string]int{},
+	}
+	for callType, count := range m.lastLoopAPICalls {
+		metrics.LastLoop[callType] = count
+	}
+	for callType, count := range m.totalAPICalls {
+		metrics.Total[callType] = count
+	}
+	m.statusLock.Unlock()
+
+	data, err := json.Marshal(metrics)
+	if err != nil {
+		glog.Errorf("Failed to encode metrics: %v", err)
+		http.Error(w, "failed to encode metrics", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// ServeHTTP serves the status computed during the most recent munge
+// loop as JSON.
+func (m *MilestoneMaintainer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.optionsLock.RLock()
+	defer m.optionsLock.RUnlock()
+
+	m.statusLock.Lock()
+	status := m.currentStatus
+	if status == nil {
+		status = m.newStatus()
+	}
+	m.statusLock.Unlock()
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		glog.Errorf("Failed to encode milestone status: %v", err)
+		http.Error(w, "failed to encode status", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
@@ -0,0 +1,331 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mungers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	githubapi "github.com/google/go-github/github"
+
+	"k8s.io/test-infra/mungegithub/github"
+	github_test "k8s.io/test-infra/mungegithub/github/testing"
+)
+
+func TestMilestoneStatusEndpoint(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeFreeze)
+
+	// A removal candidate and an issue with incomplete labels
+	removable := milestoneTestObject([]string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel}, m.activeMilestone, nil, nil)
+	incomplete := milestoneTestObject([]string{"sig/node"}, m.activeMilestone, nil, nil)
+	m.Munge(removable)
+	m.Munge(incomplete)
+
+	// The next loop publishes the accumulated status
+	if err := m.EachLoop(); err != nil {
+		t.Fatalf("unexpected error from EachLoop: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	m.ServeHTTP(recorder, httptest.NewRequest("GET", "/milestone-status", nil))
+	if recorder.Code != 200 {
+		t.Fatalf("expected status 200, got %d", recorder.Code)
+	}
+
+	status := &milestoneStatus{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), status); err != nil {
+		t.Fatalf("failed to decode status payload: %v", err)
+	}
+	if status.Milestone != m.activeMilestone {
+		t.Errorf("expected milestone %q, got %q", m.activeMilestone, status.Milestone)
+	}
+	if status.Mode != milestoneModeFreeze {
+		t.Errorf("expected mode %q, got %q", milestoneModeFreeze, status.Mode)
+	}
+	if status.FreezeDate != m.freezeDate {
+		t.Errorf("expected freeze date %q, got %q", m.freezeDate, status.FreezeDate)
+	}
+	if status.Counts[milestoneNeedsRemoval.String()] != 1 {
+		t.Errorf("expected 1 issue pending removal, got %d", status.Counts[milestoneNeedsRemoval.String()])
+	}
+	if status.Counts[milestoneNeedsLabeling.String()] != 1 {
+		t.Errorf("expected 1 issue needing labels, got %d", status.Counts[milestoneNeedsLabeling.String()])
+	}
+	if issues := status.Issues[milestoneNeedsRemoval.String()]; len(issues) != 1 {
+		t.Errorf("expected the removal candidate to be listed, got %v", issues)
+	}
+}
+
+func TestBatchReconcile(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeFreeze)
+	m.config = &github.Config{Org: "org", Project: "project", BotName: milestoneTestBotName, DryRun: true}
+
+	removable := milestoneTestObject([]string{"kind/bug", "priority/important-soon", "sig/node", statusApprovedLabel}, m.activeMilestone, nil, nil)
+	incomplete := milestoneTestObject([]string{"sig/node"}, m.activeMilestone, nil, nil)
+	otherMilestone := milestoneTestObject([]string{"sig/node"}, "v1.9", nil, nil)
+
+	result := m.reconcileObjects([]*github.MungeObject{removable, incomplete, otherMilestone})
+
+	if result.Considered != 2 {
+		t.Errorf("expected 2 issues considered, got %d", result.Considered)
+	}
+	if result.Skipped != 1 {
+		t.Errorf("expected 1 issue skipped, got %d", result.Skipped)
+	}
+	if !result.DryRun {
+		t.Errorf("expected the result to report dry-run mode")
+	}
+	if result.Counts[milestoneNeedsRemoval.String()] != 1 {
+		t.Errorf("expected 1 issue pending removal, got %d", result.Counts[milestoneNeedsRemoval.String()])
+	}
+	if result.Counts[milestoneNeedsLabeling.String()] != 1 {
+		t.Errorf("expected 1 issue needing labels, got %d", result.Counts[milestoneNeedsLabeling.String()])
+	}
+
+	recorder := httptest.NewRecorder()
+	m.handleReconcile(recorder, httptest.NewRequest("GET", "/milestone-reconcile", nil))
+	if recorder.Code != 405 {
+		t.Errorf("expected status 405 for GET, got %d", recorder.Code)
+	}
+}
+
+func TestPreviewEndpoint(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeDev)
+
+	post := func(body string) (*milestonePreviewResponse, int) {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("POST", "/milestone-preview", strings.NewReader(body))
+		m.handlePreview(recorder, request)
+		if recorder.Code != 200 {
+			return nil, recorder.Code
+		}
+		response := &milestonePreviewResponse{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+			t.Fatalf("failed to decode preview response: %v", err)
+		}
+		return response, recorder.Code
+	}
+
+	// An unapproved issue renders the approval warning.
+	response, code := post(`{"labels": ["kind/bug", "priority/important-soon", "sig/node"]}`)
+	if code != 200 {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if response.State != milestoneNeedsApproval.String() {
+		t.Errorf("expected state %q, got %q", milestoneNeedsApproval, response.State)
+	}
+	if !strings.Contains(response.Body, "**Heads-up**") || !strings.Contains(response.Body, statusApprovedLabel) {
+		t.Errorf("expected an approval warning, got %q", response.Body)
+	}
+
+	// A blocker past its update interval in an overridden mode renders
+	// the update warning.
+	response, code = post(`{"labels": ["kind/bug", "priority/critical-urgent", "sig/node", "status/approved-for-milestone", "status/in-progress"], "mode": "slush", "sinceUpdate": "96h"}`)
+	if code != 200 {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if response.State != milestoneNeedsAttention.String() {
+		t.Errorf("expected state %q, got %q", milestoneNeedsAttention, response.State)
+	}
+	if !strings.Contains(response.Body, "has not been updated since") {
+		t.Errorf("expected an update warning, got %q", response.Body)
+	}
+
+	if _, code := post(`{"mode": "bogus"}`); code != 400 {
+		t.Errorf("expected status 400 for an invalid mode, got %d", code)
+	}
+	recorder := httptest.NewRecorder()
+	m.handlePreview(recorder, httptest.NewRequest("GET", "/milestone-preview", nil))
+	if recorder.Code != 405 {
+		t.Errorf("expected status 405 for GET, got %d", recorder.Code)
+	}
+}
+
+func TestHealthEndpoint(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeDev)
+	m.healthThreshold = time.Hour
+	clock := &fakeClock{current: time.Date(2018, time.May, 15, 12, 0, 0, 0, time.UTC)}
+	m.clock = clock
+
+	health := func() (*milestoneHealth, int) {
+		recorder := httptest.NewRecorder()
+		m.handleHealth(recorder, httptest.NewRequest("GET", "/milestone-health", nil))
+		health := &milestoneHealth{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), health); err != nil {
+			t.Fatalf("failed to decode health payload: %v", err)
+		}
+		return health, recorder.Code
+	}
+
+	// No loop has completed yet.
+	if result, code := health(); result.Healthy || code != 503 {
+		t.Errorf("expected unhealthy before the first loop, got healthy=%t code=%d", result.Healthy, code)
+	}
+
+	if err := m.EachLoop(); err != nil {
+		t.Fatalf("unexpected error from EachLoop: %v", err)
+	}
+	if result, code := health(); !result.Healthy || code != 200 {
+		t.Errorf("expected healthy after a loop, got healthy=%t code=%d", result.Healthy, code)
+	}
+
+	// The heartbeat ages past the threshold.
+	clock.current = clock.current.Add(2 * time.Hour)
+	if result, code := health(); result.Healthy || code != 503 {
+		t.Errorf("expected unhealthy after the threshold, got healthy=%t code=%d", result.Healthy, code)
+	}
+}
+
+func TestAPICallCounts(t *testing.T) {
+	m := milestoneTestMaintainer(milestoneModeDev)
+	config := &github.Config{Org: "org", Project: "project", BotName: milestoneTestBotName, DryRun: true}
+	m.config = config
+
+	issue := github_test.Issue("user", 1, []string{"sig/node"}, false)
+	issue.Milestone = &githubapi.Milestone{Title: &m.activeMilestone}
+	obj := github.NewTestObject(config, issue, nil, nil)
+
+	// Munging an incomplete issue labels it and posts a warning.
+	m.Munge(obj)
+	counts := config.APICallCounts()
+	if counts["add-label"] == 0 {
+		t.Errorf("expected add-label calls to be counted, got %v", counts)
+	}
+	if counts["post-comment"] == 0 {
+		t.Errorf("expected post-comment calls to be counted, got %v", counts)
+	}
+
+	// The end of a loop snapshots the counts and accumulates totals.
+	m.recordAPICallCounts()
+	if remaining := config.APICallCounts(); len(remaining) != 0 {
+		t.Errorf("expected the loop snapshot to reset the counters, got %v", remaining)
+	}
+
+	recorder := httptest.NewRecorder()
+	m.handleMetrics(recorder, httptest.NewRequest("GET", "/milestone-metrics", nil))
+	if recorder.Code != 200 {
+		t.Fatalf("expected status 200, got %d", recorder.Code)
+	}
+	metrics := &milestoneMetrics{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), metrics); err != nil {
+		t.Fatalf("failed to decode metrics payload: %v", err)
+	}
+	if metrics.LastLoop["post-comment"] != counts["post-comment"] {
+		t.Errorf("expected the last loop to report %d post-comment calls, got %d", counts["post-comment"], metrics.LastLoop["post-comment"])
+	}
+	if metrics.Total["add-label"] != counts["add-label"] {
+		t.Errorf("expected the total to report %d add-label calls, got %d", counts["add-label"], metrics.Total["add-label"])
+	}
+
+	// A quiet loop leaves the totals intact.
+	m.recordAPICallCounts()
+	recorder = httptest.NewRecorder()
+	m.handleMetrics(recorder, httptest.NewRequest("GET", "/milestone-metrics", nil))
+	metrics = &milestoneMetrics{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), metrics); err != nil {
+		t.Fatalf("failed to decode metrics payload: %v", err)
+	}
+	if len(metrics.LastLoop) != 0 {
+		t.Errorf("expected an idle loop to report no calls, got %v", metrics.LastLoop)
+	}
+	if metrics.Total["post-comment"] != counts["post-comment"] {
+		t.Errorf("expected the total to survive an idle loop, got %v", metrics.Total)
+	}
+}
+
+func TestTrackingSummary(t *testing.T) {
+	posts := 0
+	deletes := 0
+	comments := []map[string]interface{}{}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/repos/org/project/issues/7", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"number": 7, "state": "open", "user": {"login": "lead"}}`)
+	})
+	mux.HandleFunc("/repos/org/project/issues/7/comments", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts++
+			payload := map[string]string{}
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				t.Fatalf("failed to decode comment payload: %v", err)
+			}
+			comments = append(comments, map[string]interface{}{
+				"id":         posts,
+				"body":       payload["body"],
+				"user":       map[string]string{"login": milestoneTestBotName},
+				"created_at": "2018-05-15T12:00:00Z",
+			})
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"id": %d}`, posts)
+			return
+		}
+		data, err := json.Marshal(comments)
+		if err != nil {
+			t.Fatalf("failed to encode comments: %v", err)
+		}
+		w.Write(data)
+	})
+	mux.HandleFunc("/repos/org/project/issues/comments/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deletes++
+			comments = nil
+		}
+	})
+
+	config := &github.Config{Org: "org", Project: "project", BotName: milestoneTestBotName, Endpoint: server.URL}
+	if err := config.PreExecute(); err != nil {
+		t.Fatalf("failed to initialize client: %v", err)
+	}
+	m := milestoneTestMaintainer(milestoneModeFreeze)
+	m.trackingIssueNumber = 7
+	m.config = config
+
+	atRisk := milestoneTestObject([]string{"kind/bug", blockerLabel, "sig/node", statusApprovedLabel}, m.activeMilestone, nil, nil)
+	m.recordIssueState(atRisk, milestoneAtRisk)
+	m.publishStatus()
+
+	m.postTrackingSummary()
+	if posts != 1 {
+		t.Fatalf("expected the rollup to be posted, got %d posts", posts)
+	}
+	body := comments[0]["body"].(string)
+	if !strings.Contains(body, milestoneAtRisk.String()+": 1") || !strings.Contains(body, "#1") {
+		t.Errorf("expected the rollup to summarize the at-risk issue, got %q", body)
+	}
+
+	// An unchanged rollup is left alone rather than reposted.
+	m.postTrackingSummary()
+	if posts != 1 || deletes != 0 {
+		t.Errorf("expected the unchanged rollup to be left alone, got %d posts and %d deletions", posts, deletes)
+	}
+
+	// A changed rollup replaces the previous comment.
+	m.recordIssueState(atRisk, milestoneNeedsApproval)
+	m.publishStatus()
+	m.postTrackingSummary()
+	if posts != 2 || deletes != 1 {
+		t.Errorf("expected the stale rollup to be replaced, got %d posts and %d deletions", posts, deletes)
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mungers implements the mungers run against github issues by
+// the mungegithub server.
+package mungers
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/test-infra/mungegithub/features"
+	"k8s.io/test-infra/mungegithub/github"
+	"k8s.io/test-infra/mungegithub/options"
+)
+
+// Munger is the interface which all mungers must implement to register.
+type Munger interface {
+	// Name is the name usable in --mungers
+	Name() string
+	// RequiredFeatures is a slice of 'features' that must be provided
+	RequiredFeatures() []string
+	// Initialize is called before the munger loop starts.
+	Initialize(config *github.Config, features *features.Features) error
+	// EachLoop is called at the start of every munge loop.
+	EachLoop() error
+	// RegisterOptions registers options for the munger; returns any
+	// options that require a restart when they are changed.
+	RegisterOptions(opts *options.Options) sets.String
+	// Munge is called once per issue per munge loop.
+	Munge(obj *github.MungeObject)
+}
+
+var mungerMap = map[string]Munger{}
+
+// GetAllMungers returns all registered mungers.
+func GetAllMungers() []Munger {
+	result := []Munger{}
+	for _, munger := range mungerMap {
+		result = append(result, munger)
+	}
+	return result
+}
+
+// GetActiveMungers returns the mungers with the given names.
+func GetActiveMungers(names []string) ([]Munger, error) {
+	result := make([]Munger, 0, len(names))
+	for _, name := range names {
+		munger, found := mungerMap[name]
+		if !found {
+			return nil, fmt.Errorf("couldn't find a munger named: %q", name)
+		}
+		result = append(result, munger)
+	}
+	return result, nil
+}
+
+// RegisterMunger registers the given munger by name.
+func RegisterMunger(munger Munger) error {
+	if _, found := mungerMap[munger.Name()]; found {
+		return fmt.Errorf("a munger with the name (%s) already exists", munger.Name())
+	}
+	mungerMap[munger.Name()] = munger
+	glog.Infof("Registered %#v at %s", munger, munger.Name())
+	return nil
+}
+
+// RegisterMungerOrDie registers the given munger, dying on failure.
+func RegisterMungerOrDie(munger Munger) {
+	if err := RegisterMunger(munger); err != nil {
+		glog.Fatalf("Failed to register munger: %s", err)
+	}
+}
+
+// MungeIssue runs the given mungers against a single issue.
+func MungeIssue(mungers []Munger, obj *github.MungeObject) {
+	for _, munger := range mungers {
+		munger.Munge(obj)
+	}
+}
+
+// EachLoop will be called before the start of every munge loop.
+func EachLoop(mungers []Munger) error {
+	for _, munger := range mungers {
+		if err := munger.EachLoop(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RegisterOptions registers the options of the given mungers; returns
+// the set of options that require a restart when they are changed.
+func RegisterOptions(opts *options.Options, mungers []Munger) sets.String {
+	immutables := sets.NewString()
+	for _, munger := range mungers {
+		immutables = immutables.Union(munger.RegisterOptions(opts))
+	}
+	return immutables
+}
+
+// InitializeMungers initializes the given mungers.
+func InitializeMungers(mungers []Munger, config *github.Config, features *features.Features) error {
+	for _, munger := range mungers {
+		if err := munger.Initialize(config, features); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RequestedFeatures returns the union of the features required by the
+// given mungers.
+func RequestedFeatures(mungers []Munger) []string {
+	requested := sets.NewString()
+	for _, munger := range mungers {
+		requested.Insert(munger.RequiredFeatures()...)
+	}
+	return requested.List()
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mungerutil provides utility functions shared by mungers.
+package mungerutil
+
+import (
+	"sort"
+	"strings"
+
+	githubapi "github.com/google/go-github/github"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// UserSet is a set of github logins.
+type UserSet sets.String
+
+// IssueUsers collects the users involved in an issue by role.
+type IssueUsers struct {
+	Author    UserSet
+	Assignees UserSet
+}
+
+// GetIssueUsers returns the users involved in the given issue.
+func GetIssueUsers(issue *githubapi.Issue) *IssueUsers {
+	users := &IssueUsers{
+		Author:    UserSet(sets.NewString()),
+		Assignees: UserSet(sets.NewString()),
+	}
+	if issue.User != nil && issue.User.Login != nil {
+		sets.String(users.Author).Insert(*issue.User.Login)
+	}
+	for _, assignee := range issue.Assignees {
+		if assignee.Login != nil {
+			sets.String(users.Assignees).Insert(*assignee.Login)
+		}
+	}
+	if issue.Assignee != nil && issue.Assignee.Login != nil {
+		sets.String(users.Assignees).Insert(*issue.Assignee.Login)
+	}
+	return users
+}
+
+// AllUsers returns the union of all users involved in the issue.
+func (users *IssueUsers) AllUsers() UserSet {
+	return UserSet(sets.String(users.Author).Union(sets.String(users.Assignees)))
+}
+
+// Mention returns a new set with each login prefixed with "@".
+func (u UserSet) Mention() UserSet {
+	mentioned := sets.NewString()
+	for _, login := range sets.String(u).List() {
+		mentioned.Insert("@" + login)
+	}
+	return UserSet(mentioned)
+}
+
+// List returns the sorted members of the set.
+func (u UserSet) List() []string {
+	list := sets.String(u).List()
+	sort.Strings(list)
+	return list
+}
+
+// Join returns the members of the set joined with spaces.
+func (u UserSet) Join() string {
+	return strings.Join(u.List(), " ")
+}
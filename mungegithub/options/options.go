@@ -0,0 +1,225 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package options provides a registry of dynamically updatable string,
+// bool, int and duration options. Mungers register the options they
+// consume and the server periodically reloads values from a config map,
+// notifying registered callbacks of any changes.
+package options
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// UpdateCallback is invoked after options values change, with the set of
+// option names whose values differ from the previous load.
+type UpdateCallback func(changed sets.String) error
+
+type option struct {
+	description  string
+	defaultValue string
+	currentValue string
+	apply        func(rawValue string) error
+}
+
+// Options is a registry of typed options keyed by name.
+type Options struct {
+	options   map[string]*option
+	callbacks []UpdateCallback
+	locks     []sync.Locker
+}
+
+// New returns an empty options registry.
+func New() *Options {
+	return &Options{options: map[string]*option{}}
+}
+
+func (o *Options) register(name, description, defaultValue string, apply func(rawValue string) error) {
+	if _, exists := o.options[name]; exists {
+		glog.Fatalf("Option %q is already registered", name)
+	}
+	if err := apply(defaultValue); err != nil {
+		glog.Fatalf("Invalid default for option %q: %v", name, err)
+	}
+	o.options[name] = &option{
+		description:  description,
+		defaultValue: defaultValue,
+		currentValue: defaultValue,
+		apply:        apply,
+	}
+}
+
+// RegisterString registers a string option with the given default.
+func (o *Options) RegisterString(ptr *string, name string, defaultValue string, description string) {
+	o.register(name, description, defaultValue, func(rawValue string) error {
+		*ptr = rawValue
+		return nil
+	})
+}
+
+// RegisterBool registers a bool option with the given default.
+func (o *Options) RegisterBool(ptr *bool, name string, defaultValue bool, description string) {
+	o.register(name, description, strconv.FormatBool(defaultValue), func(rawValue string) error {
+		value, err := strconv.ParseBool(rawValue)
+		if err != nil {
+			return err
+		}
+		*ptr = value
+		return nil
+	})
+}
+
+// RegisterInt registers an int option with the given default.
+func (o *Options) RegisterInt(ptr *int, name string, defaultValue int, description string) {
+	o.register(name, description, strconv.Itoa(defaultValue), func(rawValue string) error {
+		value, err := strconv.Atoi(rawValue)
+		if err != nil {
+			return err
+		}
+		*ptr = value
+		return nil
+	})
+}
+
+// RegisterDuration registers a duration option with the given default.
+func (o *Options) RegisterDuration(ptr *time.Duration, name string, defaultValue time.Duration, description string) {
+	o.register(name, description, defaultValue.String(), func(rawValue string) error {
+		value, err := time.ParseDuration(rawValue)
+		if err != nil {
+			return err
+		}
+		*ptr = value
+		return nil
+	})
+}
+
+// RegisterStringSlice registers a comma-separated string slice option
+// with the given default.
+func (o *Options) RegisterStringSlice(ptr *[]string, name string, defaultValue []string, description string) {
+	o.register(name, description, strings.Join(defaultValue, ","), func(rawValue string) error {
+		if rawValue == "" {
+			*ptr = []string{}
+			return nil
+		}
+		*ptr = strings.Split(rawValue, ",")
+		return nil
+	})
+}
+
+// RegisterUpdateCallback registers a callback to be invoked whenever
+// option values change.
+func (o *Options) RegisterUpdateCallback(callback UpdateCallback) {
+	o.callbacks = append(o.callbacks, callback)
+}
+
+// RegisterLock registers a lock that is held while option values and
+// derived state are updated, so that readers of the registered fields
+// can exclude concurrent updates by holding the lock's read side.
+func (o *Options) RegisterLock(lock sync.Locker) {
+	o.locks = append(o.locks, lock)
+}
+
+// PopulateFromString loads option values from the given config map
+// contents (one "name=value" pair per line, '#' comments allowed) and
+// returns the set of options whose values changed. Options absent from
+// the contents revert to their defaults.
+func (o *Options) PopulateFromString(contents string) sets.String {
+	rawValues := map[string]string{}
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, "=", 2)
+		if len(fields) != 2 {
+			glog.Errorf("Invalid option line %q", line)
+			continue
+		}
+		rawValues[strings.TrimSpace(fields[0])] = strings.TrimSpace(fields[1])
+	}
+	return o.populate(rawValues)
+}
+
+func (o *Options) populate(rawValues map[string]string) sets.String {
+	for _, lock := range o.locks {
+		lock.Lock()
+		defer lock.Unlock()
+	}
+	changed := sets.NewString()
+	for name, opt := range o.options {
+		rawValue, specified := rawValues[name]
+		if !specified {
+			rawValue = opt.defaultValue
+		}
+		if rawValue == opt.currentValue {
+			continue
+		}
+		if err := opt.apply(rawValue); err != nil {
+			glog.Errorf("Ignoring invalid value %q for option %q: %v", rawValue, name, err)
+			continue
+		}
+		opt.currentValue = rawValue
+		changed.Insert(name)
+	}
+	for name := range rawValues {
+		if _, registered := o.options[name]; !registered {
+			glog.Warningf("Ignoring unregistered option %q", name)
+		}
+	}
+	if changed.Len() > 0 {
+		for _, callback := range o.callbacks {
+			if err := callback(changed); err != nil {
+				glog.Errorf("Error from options update callback: %v", err)
+			}
+		}
+	}
+	return changed
+}
+
+// CurrentValues returns the current raw value of every registered
+// option, for surfacing in logs or status endpoints.
+func (o *Options) CurrentValues() map[string]string {
+	values := map[string]string{}
+	for name, opt := range o.options {
+		values[name] = opt.currentValue
+	}
+	return values
+}
+
+// Descriptions returns a human-readable summary of all registered
+// options, their defaults and descriptions.
+func (o *Options) Descriptions() string {
+	names := []string{}
+	for name := range o.options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := []string{}
+	for _, name := range names {
+		opt := o.options[name]
+		lines = append(lines, fmt.Sprintf("%s (default %q): %s", name, opt.defaultValue, opt.description))
+	}
+	return strings.Join(lines, "\n")
+}